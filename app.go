@@ -14,14 +14,18 @@ import (
 	"time"
 
 	"nuclei-poc-manager/internal/models"
+	"nuclei-poc-manager/internal/oast"
 	"nuclei-poc-manager/internal/poc"
 	"nuclei-poc-manager/internal/scanner"
+	"nuclei-poc-manager/internal/scheduler"
 )
 
 type App struct {
 	ctx        context.Context
 	pocManager *poc.Manager
 	scanner    *scanner.Scanner
+	oast       *oast.Server // 内置带外交互服务（未启用时为 nil）
+	scheduler  *scheduler.Scheduler
 	mu         sync.RWMutex
 }
 
@@ -43,8 +47,8 @@ func (a *App) startup(ctx context.Context) {
 	settingsPath := filepath.Join(dataDir, "settings.json")
 	templatesDir := filepath.Join(dataDir, "templates")
 
+	var settings models.Settings
 	if data, err := os.ReadFile(settingsPath); err == nil {
-		var settings models.Settings
 		if json.Unmarshal(data, &settings) == nil && settings.TemplatesDir != "" {
 			templatesDir = settings.TemplatesDir
 		}
@@ -59,6 +63,38 @@ func (a *App) startup(ctx context.Context) {
 
 	a.pocManager = poc.NewManager(templatesDir)
 	a.scanner = scanner.NewScanner(scansDir)
+	a.scanner.SetMaxScans(settings.MaxConcurrentScans)
+
+	// 启动内置 OAST 服务（失败不影响其他功能，保存设置时可重试）
+	if err := a.applyOASTSettings(settings.OAST); err != nil {
+		fmt.Fprintf(os.Stderr, "OAST 服务启动失败: %v\n", err)
+	}
+
+	// 定时扫描（启动时按策略处理关闭期间错过的执行）
+	a.scheduler = scheduler.NewScheduler(dataDir, a.StartScan)
+	a.scheduler.Start()
+}
+
+// applyOASTSettings 按设置重启内置 OAST 服务（未启用时关闭）
+func (a *App) applyOASTSettings(cfg models.OASTSettings) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.oast != nil {
+		a.oast.Close()
+		a.oast = nil
+		a.scanner.SetOAST(nil)
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	srv := oast.NewServer(cfg)
+	if err := srv.Start(); err != nil {
+		return err
+	}
+	a.oast = srv
+	a.scanner.SetOAST(srv)
+	return nil
 }
 
 // ReloadTemplates 重新加载模板（当设置改变时调用）
@@ -80,9 +116,15 @@ func (a *App) ReloadTemplates(templatesDir string) error {
 }
 
 func (a *App) shutdown(ctx context.Context) {
+	if a.scheduler != nil {
+		a.scheduler.Stop()
+	}
 	if a.scanner != nil {
 		a.scanner.Stop()
 	}
+	if a.oast != nil {
+		a.oast.Close()
+	}
 }
 
 // GetAllPOCs 获取所有POC模板
@@ -371,27 +413,54 @@ func (a *App) ExportPOC(id string) (string, error) {
 
 // StartScan 开始扫描
 func (a *App) StartScan(request models.ScanRequest) (string, error) {
+	templates, workflows, err := a.resolveScanTemplates(request.TemplateIDs, request.WorkflowIDs)
+	if err != nil {
+		return "", err
+	}
+
+	scanID, err := a.scanner.Start(a.ctx, request.Targets, request.TargetsFile, templates, workflows, a.pocManager.GetTemplatesDir(), request.Options, request.Name, request.Priority)
+	if err != nil {
+		return "", err
+	}
+
+	return scanID, nil
+}
+
+// resolveScanTemplates 按 ID 加载扫描使用的模板和工作流，不存在的模板会被跳过
+func (a *App) resolveScanTemplates(templateIDs, workflowIDs []string) ([]models.POCTemplate, []models.Workflow, error) {
 	var templates []models.POCTemplate
-	var skipped []string
-	for _, id := range request.TemplateIDs {
+	workflowIDs = append([]string(nil), workflowIDs...)
+	for _, id := range templateIDs {
 		t, err := a.pocManager.GetByID(id)
 		if err != nil {
-			skipped = append(skipped, id)
+			continue
+		}
+		// 选中的工作流文件按工作流执行
+		if t.Workflow {
+			workflowIDs = append(workflowIDs, id)
 			continue
 		}
 		templates = append(templates, *t)
 	}
 
-	if len(templates) == 0 {
-		return "", fmt.Errorf("没有有效的模板")
+	var workflows []models.Workflow
+	seen := make(map[string]bool)
+	for _, id := range workflowIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		wf, err := a.pocManager.ResolveWorkflow(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		workflows = append(workflows, *wf)
 	}
 
-	scanID, err := a.scanner.Start(a.ctx, request.Targets, templates, a.pocManager.GetTemplatesDir(), request.Options, request.Name)
-	if err != nil {
-		return "", err
+	if len(templates) == 0 && len(workflows) == 0 {
+		return nil, nil, fmt.Errorf("没有有效的模板")
 	}
-
-	return scanID, nil
+	return templates, workflows, nil
 }
 
 // StopScan 停止扫描
@@ -399,6 +468,56 @@ func (a *App) StopScan(scanID string) error {
 	return a.scanner.StopScan(scanID)
 }
 
+// SetScanPriority 修改排队中扫描的优先级
+func (a *App) SetScanPriority(scanID string, priority int) error {
+	return a.scanner.SetScanPriority(scanID, priority)
+}
+
+// MoveQueuedScan 调整排队中扫描在队列中的位置（从 1 开始）
+func (a *App) MoveQueuedScan(scanID string, position int) error {
+	return a.scanner.MoveQueuedScan(scanID, position)
+}
+
+// PauseScan 暂停扫描
+func (a *App) PauseScan(scanID string) error {
+	return a.scanner.PauseScan(scanID)
+}
+
+// ResumeScan 从断点继续扫描，模板按原扫描的模板和工作流 ID 重新加载
+func (a *App) ResumeScan(scanID string) error {
+	status, err := a.scanner.GetStatus(scanID)
+	if err != nil {
+		return err
+	}
+
+	templates, workflows, err := a.resolveScanTemplates(status.TemplateIDs, status.WorkflowIDs)
+	if err != nil {
+		return err
+	}
+
+	return a.scanner.ResumeScan(a.ctx, scanID, templates, workflows, a.pocManager.GetTemplatesDir())
+}
+
+// GetSchedules 获取所有定时扫描
+func (a *App) GetSchedules() []models.Schedule {
+	return a.scheduler.List()
+}
+
+// CreateSchedule 创建定时扫描
+func (a *App) CreateSchedule(schedule models.Schedule) (*models.Schedule, error) {
+	return a.scheduler.Create(schedule)
+}
+
+// UpdateSchedule 修改定时扫描
+func (a *App) UpdateSchedule(schedule models.Schedule) (*models.Schedule, error) {
+	return a.scheduler.Update(schedule)
+}
+
+// DeleteSchedule 删除定时扫描
+func (a *App) DeleteSchedule(id string) error {
+	return a.scheduler.Delete(id)
+}
+
 // GetScanStatus 获取扫描状态
 func (a *App) GetScanStatus(scanID string) (*models.ScanStatus, error) {
 	return a.scanner.GetStatus(scanID)
@@ -424,6 +543,16 @@ func (a *App) ExportScanResults(scanID string) (string, error) {
 	return a.scanner.ExportScanResults(scanID)
 }
 
+// CompareScans 对比两次扫描的发现（新增、已修复、仍存在）
+func (a *App) CompareScans(baseScanID, newScanID string) (*models.ScanDiff, error) {
+	return a.scanner.CompareScans(baseScanID, newScanID)
+}
+
+// ExportScanDiff 导出两次扫描的对比结果
+func (a *App) ExportScanDiff(baseScanID, newScanID string) (string, error) {
+	return a.scanner.ExportScanDiff(baseScanID, newScanID)
+}
+
 // ValidatePOCYAML 验证POC YAML格式
 func (a *App) ValidatePOCYAML(content string) error {
 	_, err := a.pocManager.ParseYAML(content)
@@ -509,7 +638,14 @@ func (a *App) SaveSettings(settings models.Settings) error {
 		return err
 	}
 
-	return os.WriteFile(settingsPath, data, 0644)
+	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
+		return err
+	}
+	a.scanner.SetMaxScans(settings.MaxConcurrentScans)
+	if err := a.applyOASTSettings(settings.OAST); err != nil {
+		return fmt.Errorf("设置已保存，但 OAST 服务启动失败: %w", err)
+	}
+	return nil
 }
 
 // LoadSettings 加载设置
@@ -528,11 +664,16 @@ func (a *App) LoadSettings() (*models.Settings, error) {
 				templatesDir = a.pocManager.GetTemplatesDir()
 			}
 			return &models.Settings{
-				Concurrency:  10,
-				Timeout:      30,
-				RateLimit:    100,
-				BulkSize:     25,
-				TemplatesDir: templatesDir,
+				Concurrency:        10,
+				Timeout:            30,
+				RateLimit:          100,
+				BulkSize:           25,
+				TemplatesDir:       templatesDir,
+				MaxConcurrentScans: scanner.DefaultMaxScans,
+				OAST: models.OASTSettings{
+					DNSPort:  53,
+					HTTPPort: 80,
+				},
 			}, nil
 		}
 		return nil, err
@@ -1,6 +1,6 @@
 import { useState, useEffect, lazy, Suspense, useCallback, memo } from 'react'
 import Sidebar from './components/Sidebar'
-import { ViewType, POCTemplate, Stats } from './types'
+import { ViewType, POCTemplate, ScanDiff, Schedule, Stats } from './types'
 import { Loader } from 'lucide-react'
 
 // 懒加载组件 - 提升首屏加载速度
@@ -8,6 +8,7 @@ const Dashboard = lazy(() => import('./components/Dashboard'))
 const TemplateList = lazy(() => import('./components/TemplateList'))
 const TemplateEditor = lazy(() => import('./components/TemplateEditor'))
 const Scanner = lazy(() => import('./components/Scanner'))
+const Schedules = lazy(() => import('./components/Schedules'))
 const Results = lazy(() => import('./components/Results'))
 const Tools = lazy(() => import('./components/Tools'))
 const Settings = lazy(() => import('./components/Settings'))
@@ -48,11 +49,21 @@ declare global {
           ExportPOC: (id: string) => Promise<string>;
           StartScan: (request: any) => Promise<string>;
           StopScan: (scanId: string) => Promise<void>;
+          PauseScan: (scanId: string) => Promise<void>;
+          SetScanPriority: (scanId: string, priority: number) => Promise<void>;
+          MoveQueuedScan: (scanId: string, position: number) => Promise<void>;
+          GetSchedules: () => Promise<Schedule[]>;
+          CreateSchedule: (schedule: Schedule) => Promise<Schedule>;
+          UpdateSchedule: (schedule: Schedule) => Promise<Schedule>;
+          DeleteSchedule: (id: string) => Promise<void>;
+          ResumeScan: (scanId: string) => Promise<void>;
           GetScanStatus: (scanId: string) => Promise<any>;
           GetScanResults: (scanId: string) => Promise<any[]>;
           GetAllScans: () => Promise<any[]>;
           DeleteScan: (scanId: string) => Promise<void>;
           ExportScanResults: (scanId: string) => Promise<string>;
+          CompareScans: (baseScanId: string, newScanId: string) => Promise<ScanDiff>;
+          ExportScanDiff: (baseScanId: string, newScanId: string) => Promise<string>;
           ValidatePOCYAML: (content: string) => Promise<void>;
           GetTemplatesDir: () => Promise<string>;
           GetStats: () => Promise<Stats>;
@@ -97,6 +108,7 @@ function App() {
           '4': 'results',
           '5': 'tools',
           '6': 'settings',
+          '7': 'schedules',
         }
         if (viewMap[e.key]) {
           e.preventDefault()
@@ -220,6 +232,8 @@ function App() {
             onViewResult={handleViewScanResult}
           />
         )
+      case 'schedules':
+        return <Schedules onViewResult={handleViewScanResult} />
       case 'results':
         return (
           <Results 
@@ -12,9 +12,11 @@ import {
   ArrowRight,
   Send,
   FileText,
+  GitCompare,
+  Download,
   X
 } from 'lucide-react'
-import { ScanResult, ScanStatus, POCTemplate } from '../types'
+import { ScanResult, ScanStatus, ScanDiff, POCTemplate } from '../types'
 import { BrowserOpenURL } from '../../wailsjs/runtime/runtime'
 import toast from 'react-hot-toast'
 
@@ -33,6 +35,9 @@ function Results({ initialScanId, onViewPOC }: ResultsProps) {
   const [filterType, setFilterType] = useState<'all' | 'found' | 'error'>('found')
   const [viewingPacket, setViewingPacket] = useState<ScanResult | null>(null)
   const [packetTab, setPacketTab] = useState<'request' | 'response'>('request')
+  const [compareBase, setCompareBase] = useState<string>('')
+  const [diff, setDiff] = useState<ScanDiff | null>(null)
+  const [diffTab, setDiffTab] = useState<'new' | 'fixed' | 'persisting' | 'unverified'>('new')
 
   useEffect(() => {
     loadScans()
@@ -50,6 +55,14 @@ function Results({ initialScanId, onViewPOC }: ResultsProps) {
     }
   }, [selectedScan])
 
+  useEffect(() => {
+    if (selectedScan && compareBase && compareBase !== selectedScan) {
+      loadDiff(compareBase, selectedScan)
+    } else {
+      setDiff(null)
+    }
+  }, [selectedScan, compareBase])
+
   const loadScans = async () => {
     try {
       if (window.go?.main?.App?.GetAllScans) {
@@ -78,6 +91,37 @@ function Results({ initialScanId, onViewPOC }: ResultsProps) {
     }
   }
 
+  const loadDiff = async (baseScanId: string, newScanId: string) => {
+    try {
+      if (window.go?.main?.App?.CompareScans) {
+        const data = await window.go.main.App.CompareScans(baseScanId, newScanId)
+        setDiff(data)
+      }
+    } catch (error: any) {
+      setDiff(null)
+      toast.error('对比失败: ' + (error?.message || error || '未知错误'))
+    }
+  }
+
+  const handleExportDiff = async () => {
+    if (!diff) return
+    try {
+      if (window.go?.main?.App?.ExportScanDiff) {
+        const json = await window.go.main.App.ExportScanDiff(diff.baseScanId, diff.newScanId)
+        const blob = new Blob([json], { type: 'application/json' })
+        const url = URL.createObjectURL(blob)
+        const a = document.createElement('a')
+        a.href = url
+        a.download = `${diff.baseScanId}_vs_${diff.newScanId}_diff.json`
+        a.click()
+        URL.revokeObjectURL(url)
+        toast.success('导出成功')
+      }
+    } catch (error: any) {
+      toast.error('导出失败: ' + (error?.message || '未知错误'))
+    }
+  }
+
   const handleViewPOC = async (templateId: string) => {
     try {
       if (window.go?.main?.App?.GetPOCByID) {
@@ -159,7 +203,7 @@ function Results({ initialScanId, onViewPOC }: ResultsProps) {
                   <h3 className="text-lg font-semibold text-white">
                     {viewingPacket.templateName || viewingPacket.templateId}
                   </h3>
-                  <p className="text-sm text-dark-400">{viewingPacket.host}</p>
+                  <p className="text-sm text-dark-400">{viewingPacket.filePath || viewingPacket.host}</p>
                 </div>
               </div>
               <button
@@ -326,6 +370,78 @@ function Results({ initialScanId, onViewPOC }: ResultsProps) {
         </div>
       </div>
 
+      {/* 扫描对比：以基准扫描为参照，查看当前扫描新增、已修复和仍存在的发现 */}
+      {selectedScan && (
+        <div className="card p-4 space-y-3">
+          <div className="flex items-center gap-4">
+            <GitCompare className="w-5 h-5 text-cyber-400" />
+            <div className="flex-1">
+              <select
+                value={compareBase}
+                onChange={(e) => setCompareBase(e.target.value)}
+                className="w-full"
+              >
+                <option value="">与之前的扫描对比...</option>
+                {scans.filter(scan => scan.id !== selectedScan).map(scan => (
+                  <option key={scan.id} value={scan.id}>
+                    基准: {scan.name || scan.id} ({scan.found} 发现)
+                  </option>
+                ))}
+              </select>
+            </div>
+            {diff && (
+              <button onClick={handleExportDiff} className="btn btn-secondary btn-sm">
+                <Download className="w-4 h-4" />
+                导出对比
+              </button>
+            )}
+          </div>
+
+          {diff && (
+            <>
+              <div className="flex gap-2 pt-2 border-t border-dark-700/50">
+                {[
+                  { key: 'new', label: '新增', count: diff.new.length, color: 'bg-orange-500/20 text-orange-400 border-orange-500/30' },
+                  { key: 'fixed', label: '已修复', count: diff.fixed.length, color: 'bg-green-500/20 text-green-400 border-green-500/30' },
+                  { key: 'persisting', label: '仍存在', count: diff.persisting.length, color: 'bg-yellow-500/20 text-yellow-400 border-yellow-500/30' },
+                  { key: 'unverified', label: '未复测', count: diff.unverified.length, color: 'bg-dark-600 text-dark-300 border-dark-500' },
+                ].map(t => (
+                  <button
+                    key={t.key}
+                    onClick={() => setDiffTab(t.key as any)}
+                    title={t.key === 'unverified' ? '新扫描未执行该模板或请求失败，无法确认是否已修复' : undefined}
+                    className={`px-4 py-1.5 rounded-lg text-sm border transition-all
+                      ${diffTab === t.key ? t.color : 'text-dark-500 border-dark-700 hover:border-dark-500'}`}
+                  >
+                    {t.label} ({t.count})
+                  </button>
+                ))}
+              </div>
+              {diff[diffTab].length === 0 ? (
+                <p className="text-sm text-dark-500">无</p>
+              ) : (
+                <div className="divide-y divide-dark-700/50 max-h-80 overflow-y-auto">
+                  {diff[diffTab].map(result => (
+                    <div key={result.id} className="flex items-center gap-3 py-2 text-sm">
+                      <span className={`tag ${getSeverityClass(result.severity)}`}>{result.severity}</span>
+                      <span className="text-white truncate">{result.templateName || result.templateId}</span>
+                      <span className="text-dark-400 truncate flex-1">{result.filePath || result.host}</span>
+                      <button
+                        onClick={() => setViewingPacket(result)}
+                        className="btn btn-secondary btn-sm"
+                        title="查看请求/响应数据包"
+                      >
+                        <Send className="w-4 h-4" />
+                      </button>
+                    </div>
+                  ))}
+                </div>
+              )}
+            </>
+          )}
+        </div>
+      )}
+
       {/* 统计概览 */}
       {results.length > 0 && (
         <div className="grid grid-cols-5 gap-4">
@@ -424,7 +540,8 @@ function Results({ initialScanId, onViewPOC }: ResultsProps) {
                       )}
                     </div>
                     <div className="text-sm text-dark-400 truncate mt-1">
-                      {result.host}{result.matched && <span className="text-orange-400 ml-2">— {result.matched}</span>}
+                      {result.filePath ? `${result.filePath}${result.lines?.length ? `:${result.lines.join(',')}` : ''}` : result.host}
+                      {result.matched && <span className="text-orange-400 ml-2">— {result.matched}</span>}
                       {result.error && <span className="text-red-400 ml-2">— {result.error}</span>}
                     </div>
                   </div>
@@ -477,7 +594,7 @@ function Results({ initialScanId, onViewPOC }: ResultsProps) {
                               <span className="text-dark-500 text-sm">{key}:</span>
                               <code className="px-2 py-1 rounded bg-dark-900 text-dark-200
                                              font-mono text-sm">
-                                {value}
+                                {Array.isArray(value) ? value.join(', ') : value}
                               </code>
                             </div>
                           ))}
@@ -14,9 +14,14 @@ import {
   Activity,
   Search,
   Trash2,
-  Download
+  Download,
+  Pause,
+  Play,
+  ArrowUp,
+  ArrowDown,
+  CalendarClock
 } from 'lucide-react'
-import { POCTemplate, ScanStatus } from '../types'
+import { POCTemplate, ScanRequest, ScanStatus } from '../types'
 import toast from 'react-hot-toast'
 
 interface ScannerProps {
@@ -29,7 +34,14 @@ const MAX_DISPLAY = 100 // 最多显示100个模板
 
 function Scanner({ templates, loading, onViewResult }: ScannerProps) {
   const [taskName, setTaskName] = useState('')
+  const [priority, setPriority] = useState(0)
+  const [scheduleType, setScheduleType] = useState<'cron' | 'interval'>('cron')
+  const [scheduleCron, setScheduleCron] = useState('0 2 * * 1')
+  const [scheduleInterval, setScheduleInterval] = useState(1440)
+  const [missedPolicy, setMissedPolicy] = useState<'skip' | 'run-once'>('skip')
+  const [savingSchedule, setSavingSchedule] = useState(false)
   const [targets, setTargets] = useState('')
+  const [targetsFile, setTargetsFile] = useState('')
   const [selectedTemplates, setSelectedTemplates] = useState<string[]>([])
   const [activeScans, setActiveScans] = useState<ScanStatus[]>([])
   const [scanning, setScanning] = useState(false)
@@ -134,38 +146,72 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
     }
   }
 
-  const handleStartScan = async () => {
+  // 按当前表单构建扫描请求（校验失败时返回 null）
+  const buildScanRequest = (): ScanRequest | null => {
     const targetList = targets
       .split('\n')
       .map(t => t.trim())
       .filter(t => t.length > 0)
 
-    if (targetList.length === 0) {
-      toast.error('请输入至少一个目标')
-      return
+    if (targetList.length === 0 && !targetsFile.trim()) {
+      toast.error('请输入至少一个目标或目标文件')
+      return null
     }
 
     if (selectedTemplates.length === 0) {
       toast.error('请选择至少一个模板')
-      return
+      return null
+    }
+
+    return {
+      name: taskName || '',
+      priority,
+      targets: targetList,
+      targetsFile: targetsFile.trim(),
+      templateIds: selectedTemplates,
+      options: {
+        concurrency: scanSettings.concurrency,
+        timeout: scanSettings.timeout,
+        rateLimit: scanSettings.rateLimit,
+        bulkSize: 25,
+        headless: false,
+        proxyUrl: scanSettings.proxyUrl,
+      },
     }
+  }
+
+  const handleSaveSchedule = async () => {
+    const request = buildScanRequest()
+    if (!request) return
+
+    setSavingSchedule(true)
+    try {
+      if (window.go?.main?.App?.CreateSchedule) {
+        await window.go.main.App.CreateSchedule({
+          name: taskName || '定时扫描',
+          enabled: true,
+          cron: scheduleType === 'cron' ? scheduleCron.trim() : '',
+          interval: scheduleType === 'interval' ? scheduleInterval : 0,
+          missedPolicy,
+          request,
+        })
+        toast.success('定时扫描已保存')
+      }
+    } catch (error: any) {
+      toast.error('保存定时扫描失败: ' + (error?.message || error || '未知错误'))
+    } finally {
+      setSavingSchedule(false)
+    }
+  }
+
+  const handleStartScan = async () => {
+    const request = buildScanRequest()
+    if (!request) return
 
     setScanning(true)
     try {
       if (window.go?.main?.App?.StartScan) {
-        const scanId = await window.go.main.App.StartScan({
-          name: taskName || '',
-          targets: targetList,
-          templateIds: selectedTemplates,
-          options: {
-            concurrency: scanSettings.concurrency,
-            timeout: scanSettings.timeout,
-            rateLimit: scanSettings.rateLimit,
-            bulkSize: 25,
-            headless: false,
-            proxyUrl: scanSettings.proxyUrl,
-          },
-        })
+        const scanId = await window.go.main.App.StartScan(request)
         toast.success(`扫描已启动`)
         setExpandedScans(prev => [...prev, scanId])
         loadScans()
@@ -189,6 +235,52 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
     }
   }
 
+  const handleMoveQueuedScan = async (scanId: string, position: number) => {
+    try {
+      if (window.go?.main?.App?.MoveQueuedScan) {
+        await window.go.main.App.MoveQueuedScan(scanId, position)
+        loadScans()
+      }
+    } catch (error: any) {
+      toast.error('调整队列失败: ' + (error?.message || '未知错误'))
+    }
+  }
+
+  const handleSetScanPriority = async (scanId: string, value: number) => {
+    try {
+      if (window.go?.main?.App?.SetScanPriority) {
+        await window.go.main.App.SetScanPriority(scanId, value)
+        loadScans()
+      }
+    } catch (error: any) {
+      toast.error('修改优先级失败: ' + (error?.message || '未知错误'))
+    }
+  }
+
+  const handlePauseScan = async (scanId: string) => {
+    try {
+      if (window.go?.main?.App?.PauseScan) {
+        await window.go.main.App.PauseScan(scanId)
+        toast.success('扫描已暂停')
+        loadScans()
+      }
+    } catch (error: any) {
+      toast.error('暂停扫描失败: ' + (error?.message || '未知错误'))
+    }
+  }
+
+  const handleResumeScan = async (scanId: string) => {
+    try {
+      if (window.go?.main?.App?.ResumeScan) {
+        await window.go.main.App.ResumeScan(scanId)
+        toast.success('扫描已继续')
+        loadScans()
+      }
+    } catch (error: any) {
+      toast.error('继续扫描失败: ' + (error?.message || '未知错误'))
+    }
+  }
+
   const handleDeleteScan = async (scanId: string) => {
     if (!confirm('确定要删除这个扫描任务吗？')) return
     try {
@@ -263,6 +355,10 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
         return <CheckCircle className="w-5 h-5 text-green-400" />
       case 'failed':
         return <XCircle className="w-5 h-5 text-red-400" />
+      case 'queued':
+        return <Clock className="w-5 h-5 text-violet-400" />
+      case 'paused':
+        return <Pause className="w-5 h-5 text-blue-400" />
       case 'stopped':
         return <Square className="w-5 h-5 text-yellow-400" />
       default:
@@ -272,9 +368,11 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
 
   const getStatusText = (status: string) => {
     const texts: Record<string, string> = {
+      queued: '排队中',
       running: '扫描中',
       completed: '已完成',
       failed: '失败',
+      paused: '已暂停',
       stopped: '已停止',
       pending: '等待中',
     }
@@ -286,6 +384,8 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
       case 'running': return 'text-cyber-400 bg-cyber-500/20'
       case 'completed': return 'text-green-400 bg-green-500/20'
       case 'failed': return 'text-red-400 bg-red-500/20'
+      case 'queued': return 'text-violet-400 bg-violet-500/20'
+      case 'paused': return 'text-blue-400 bg-blue-500/20'
       case 'stopped': return 'text-yellow-400 bg-yellow-500/20'
       default: return 'text-dark-400 bg-dark-600'
     }
@@ -303,8 +403,10 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
 
   // 按状态排序：运行中 > 等待中 > 已完成/停止/失败
   const sortedScans = [...activeScans].sort((a, b) => {
-    const order: Record<string, number> = { running: 0, pending: 1, completed: 2, stopped: 3, failed: 4 }
-    return (order[a.status] || 5) - (order[b.status] || 5)
+    const order: Record<string, number> = { running: 0, queued: 1, pending: 2, paused: 3, completed: 4, stopped: 5, failed: 6 }
+    const diff = (order[a.status] ?? 7) - (order[b.status] ?? 7)
+    if (diff !== 0 || a.status !== 'queued') return diff
+    return (a.queuePosition || 0) - (b.queuePosition || 0)
   })
 
   return (
@@ -330,6 +432,14 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
             placeholder="输入任务名称（可选）"
             className="flex-1"
           />
+          <label className="text-sm text-dark-400 whitespace-nowrap">优先级</label>
+          <input
+            type="number"
+            value={priority}
+            onChange={(e) => setPriority(parseInt(e.target.value) || 0)}
+            title="数值越大越先执行，超出同时运行上限的扫描按优先级排队"
+            className="w-24"
+          />
         </div>
       </div>
 
@@ -350,6 +460,13 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
             rows={8}
             className="w-full font-mono text-sm resize-none"
           />
+          <input
+            type="text"
+            value={targetsFile}
+            onChange={(e) => setTargetsFile(e.target.value)}
+            placeholder="目标文件路径（可选，每行一个目标，适合大批量目标）"
+            className="w-full font-mono text-sm"
+          />
           <div className="flex items-center justify-between text-sm text-dark-500">
             <span>目标数量: {targets.split('\n').filter(t => t.trim()).length}</span>
           </div>
@@ -486,6 +603,61 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
         </button>
       </div>
 
+      {/* 保存为定时扫描 */}
+      <div className="card p-4">
+        <div className="flex flex-wrap items-center gap-4">
+          <div className="flex items-center gap-2">
+            <CalendarClock className="w-5 h-5 text-cyber-400" />
+            <span className="text-sm text-white">定时扫描</span>
+          </div>
+          <select
+            value={scheduleType}
+            onChange={(e) => setScheduleType(e.target.value as 'cron' | 'interval')}
+          >
+            <option value="cron">cron 表达式</option>
+            <option value="interval">固定间隔</option>
+          </select>
+          {scheduleType === 'cron' ? (
+            <input
+              type="text"
+              value={scheduleCron}
+              onChange={(e) => setScheduleCron(e.target.value)}
+              placeholder="分 时 日 月 周，如 0 2 * * 1"
+              title="5 段 cron 表达式（分 时 日 月 周），也支持 @daily、@weekly 等"
+              className="w-48 font-mono text-sm"
+            />
+          ) : (
+            <div className="flex items-center gap-2">
+              <span className="text-sm text-dark-400">每</span>
+              <input
+                type="number"
+                min={1}
+                value={scheduleInterval}
+                onChange={(e) => setScheduleInterval(parseInt(e.target.value) || 1)}
+                className="w-24"
+              />
+              <span className="text-sm text-dark-400">分钟</span>
+            </div>
+          )}
+          <select
+            value={missedPolicy}
+            onChange={(e) => setMissedPolicy(e.target.value as 'skip' | 'run-once')}
+            title="应用关闭期间错过的执行"
+          >
+            <option value="skip">错过时跳过</option>
+            <option value="run-once">错过时补执行一次</option>
+          </select>
+          <button
+            onClick={handleSaveSchedule}
+            disabled={savingSchedule || selectedTemplates.length === 0}
+            className="btn btn-secondary btn-sm disabled:opacity-50"
+          >
+            {savingSchedule ? <Loader className="w-4 h-4 animate-spin" /> : <CalendarClock className="w-4 h-4" />}
+            保存为定时扫描
+          </button>
+        </div>
+      </div>
+
       {/* 扫描任务列表 */}
       {sortedScans.length > 0 && (
         <div className="space-y-4">
@@ -520,6 +692,11 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
                     <span className={`text-xs px-2 py-0.5 rounded ${getStatusColor(scan.status)}`}>
                       {getStatusText(scan.status)}
                     </span>
+                    {scan.status === 'queued' && scan.queuePosition && (
+                      <span className="text-xs text-dark-400">
+                        第 {scan.queuePosition} 位 · 优先级 {scan.priority || 0}
+                      </span>
+                    )}
                     {scan.found > 0 && (
                       <span className="flex items-center gap-1 text-xs text-orange-400">
                         <AlertTriangle className="w-3 h-3" />
@@ -552,6 +729,64 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
                   <div>{formatDuration(scan.startedAt, scan.completedAt)}</div>
                 </div>
 
+                {scan.status === 'queued' && (
+                  <>
+                    <button
+                      onClick={(e) => { e.stopPropagation(); handleMoveQueuedScan(scan.id, (scan.queuePosition || 1) - 1); }}
+                      className="btn btn-secondary btn-sm"
+                      title="前移"
+                      disabled={scan.queuePosition === 1}
+                    >
+                      <ArrowUp className="w-4 h-4" />
+                    </button>
+                    <button
+                      onClick={(e) => { e.stopPropagation(); handleMoveQueuedScan(scan.id, (scan.queuePosition || 0) + 1); }}
+                      className="btn btn-secondary btn-sm"
+                      title="后移"
+                    >
+                      <ArrowDown className="w-4 h-4" />
+                    </button>
+                    <input
+                      type="number"
+                      defaultValue={scan.priority || 0}
+                      onClick={(e) => e.stopPropagation()}
+                      onBlur={(e) => {
+                        const value = parseInt(e.target.value) || 0
+                        if (value !== (scan.priority || 0)) handleSetScanPriority(scan.id, value)
+                      }}
+                      title="优先级"
+                      className="w-16 text-xs"
+                    />
+                    <button
+                      onClick={(e) => { e.stopPropagation(); handleStopScan(scan.id); }}
+                      className="btn btn-danger btn-sm"
+                    >
+                      <XCircle className="w-4 h-4" />
+                      取消
+                    </button>
+                  </>
+                )}
+
+                {scan.status === 'running' && (
+                  <button
+                    onClick={(e) => { e.stopPropagation(); handlePauseScan(scan.id); }}
+                    className="btn btn-secondary btn-sm"
+                  >
+                    <Pause className="w-4 h-4" />
+                    暂停
+                  </button>
+                )}
+
+                {scan.status !== 'running' && scan.status !== 'queued' && scan.resumable && (
+                  <button
+                    onClick={(e) => { e.stopPropagation(); handleResumeScan(scan.id); }}
+                    className="btn btn-primary btn-sm"
+                  >
+                    <Play className="w-4 h-4" />
+                    继续
+                  </button>
+                )}
+
                 {scan.status === 'running' && (
                   <button
                     onClick={(e) => { e.stopPropagation(); handleStopScan(scan.id); }}
@@ -600,7 +835,7 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
                   <div className="grid grid-cols-2 md:grid-cols-4 gap-4 py-4">
                     <div className="text-center p-3 rounded-lg bg-dark-800/50">
                       <div className="text-2xl font-bold text-white font-mono">
-                        {scan.targets?.length || 0}
+                        {scan.targetCount || scan.targets?.length || 0}
                       </div>
                       <div className="text-xs text-dark-500">目标数量</div>
                     </div>
@@ -624,6 +859,20 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
                     </div>
                   </div>
 
+                  {/* 模板聚类统计 */}
+                  {scan.requestsSaved ? (
+                    <div className="mb-2 text-xs text-dark-500">
+                      模板聚类: 节省 <span className="text-cyber-400 font-mono">{scan.requestsSaved}</span> 次请求
+                    </div>
+                  ) : null}
+
+                  {/* 请求失败统计（每个目标只保留一条错误结果） */}
+                  {scan.errors ? (
+                    <div className="mb-2 text-xs text-dark-500">
+                      请求失败: <span className="text-red-400 font-mono">{scan.errors}</span> 个目标 × 模板
+                    </div>
+                  ) : null}
+
                   {/* 目标列表 */}
                   {scan.targets && scan.targets.length > 0 && (
                     <div className="mt-2">
@@ -642,6 +891,11 @@ function Scanner({ templates, loading, onViewResult }: ScannerProps) {
                       </div>
                     </div>
                   )}
+                  {scan.targetsFile && (
+                    <div className="mt-2 text-xs text-dark-500">
+                      目标文件: <span className="text-dark-300 font-mono">{scan.targetsFile}</span>
+                    </div>
+                  )}
 
                   {scan.error && (
                     <div className="mt-3 p-3 rounded-lg bg-red-500/10 border border-red-500/20 text-red-400 text-sm">
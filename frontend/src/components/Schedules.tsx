@@ -0,0 +1,283 @@
+import { useState, useEffect } from 'react'
+import {
+  CalendarClock,
+  ChevronDown,
+  ChevronRight,
+  Trash2,
+  Loader,
+  CheckCircle,
+  XCircle,
+  SkipForward,
+  Save
+} from 'lucide-react'
+import { Schedule } from '../types'
+import toast from 'react-hot-toast'
+
+interface SchedulesProps {
+  onViewResult?: (scanId: string) => void;
+}
+
+function Schedules({ onViewResult }: SchedulesProps) {
+  const [schedules, setSchedules] = useState<Schedule[]>([])
+  const [loading, setLoading] = useState(true)
+  const [expanded, setExpanded] = useState<string[]>([])
+  const [editing, setEditing] = useState<Record<string, Schedule>>({})
+
+  useEffect(() => {
+    loadSchedules()
+    const interval = setInterval(loadSchedules, 30000)
+    return () => clearInterval(interval)
+  }, [])
+
+  const loadSchedules = async () => {
+    try {
+      if (window.go?.main?.App?.GetSchedules) {
+        const data = await window.go.main.App.GetSchedules()
+        setSchedules(data || [])
+      }
+    } catch (error) {
+      console.error(error)
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  const saveSchedule = async (schedule: Schedule, message: string) => {
+    try {
+      if (window.go?.main?.App?.UpdateSchedule) {
+        await window.go.main.App.UpdateSchedule(schedule)
+        toast.success(message)
+        setEditing(prev => {
+          const next = { ...prev }
+          delete next[schedule.id!]
+          return next
+        })
+        loadSchedules()
+      }
+    } catch (error: any) {
+      toast.error('保存失败: ' + (error?.message || error || '未知错误'))
+    }
+  }
+
+  const handleDelete = async (id: string) => {
+    if (!confirm('确定要删除这个定时扫描吗？已执行的扫描不会被删除')) return
+    try {
+      if (window.go?.main?.App?.DeleteSchedule) {
+        await window.go.main.App.DeleteSchedule(id)
+        toast.success('定时扫描已删除')
+        loadSchedules()
+      }
+    } catch (error: any) {
+      toast.error('删除失败: ' + (error?.message || error || '未知错误'))
+    }
+  }
+
+  const toggleExpand = (id: string) => {
+    setExpanded(prev =>
+      prev.includes(id) ? prev.filter(e => e !== id) : [...prev, id]
+    )
+  }
+
+  const updateEditing = (schedule: Schedule, patch: Partial<Schedule>) => {
+    setEditing(prev => ({
+      ...prev,
+      [schedule.id!]: { ...(prev[schedule.id!] || schedule), ...patch },
+    }))
+  }
+
+  const formatTime = (time?: string) => {
+    if (!time || time.startsWith('0001')) return '-'
+    return new Date(time).toLocaleString()
+  }
+
+  const describeTiming = (schedule: Schedule) => {
+    if (schedule.cron) return `cron: ${schedule.cron}`
+    const minutes = schedule.interval || 0
+    if (minutes % 1440 === 0) return `每 ${minutes / 1440} 天`
+    if (minutes % 60 === 0) return `每 ${minutes / 60} 小时`
+    return `每 ${minutes} 分钟`
+  }
+
+  if (loading) {
+    return (
+      <div className="flex items-center justify-center h-full">
+        <Loader className="w-8 h-8 text-cyber-400 animate-spin" />
+      </div>
+    )
+  }
+
+  return (
+    <div className="p-8 space-y-6">
+      {/* 页面标题 */}
+      <div>
+        <h1 className="text-3xl font-display font-bold text-white mb-2">
+          定时扫描
+        </h1>
+        <p className="text-dark-400">
+          按 cron 表达式或固定间隔重复执行扫描，在扫描器页面配置后保存为定时扫描
+        </p>
+      </div>
+
+      {schedules.length === 0 ? (
+        <div className="card p-12 text-center">
+          <CalendarClock className="w-12 h-12 text-dark-600 mx-auto mb-4" />
+          <p className="text-dark-400">暂无定时扫描</p>
+        </div>
+      ) : (
+        <div className="space-y-3">
+          {schedules.map(schedule => {
+            const draft = editing[schedule.id!]
+            const current = draft || schedule
+            return (
+              <div key={schedule.id} className="card overflow-hidden">
+                <div
+                  className="flex items-center gap-4 p-4 cursor-pointer hover:bg-dark-800/50"
+                  onClick={() => toggleExpand(schedule.id!)}
+                >
+                  <div className="text-dark-500">
+                    {expanded.includes(schedule.id!)
+                      ? <ChevronDown className="w-5 h-5" />
+                      : <ChevronRight className="w-5 h-5" />
+                    }
+                  </div>
+                  <CalendarClock className={`w-5 h-5 ${schedule.enabled ? 'text-cyber-400' : 'text-dark-500'}`} />
+                  <div className="flex-1 min-w-0">
+                    <div className="flex items-center gap-3">
+                      <span className="text-white text-sm truncate">{schedule.name}</span>
+                      <span className="text-xs text-dark-400 font-mono">{describeTiming(schedule)}</span>
+                      {!schedule.enabled && (
+                        <span className="text-xs px-2 py-0.5 rounded text-dark-400 bg-dark-600">已停用</span>
+                      )}
+                    </div>
+                    <div className="text-xs text-dark-500 mt-1">
+                      下次执行: {schedule.enabled ? formatTime(schedule.nextRun) : '-'}
+                      <span className="mx-2">·</span>
+                      上次执行: {formatTime(schedule.lastRun)}
+                      <span className="mx-2">·</span>
+                      {schedule.request.templateIds.length} 个模板
+                    </div>
+                  </div>
+
+                  <button
+                    onClick={(e) => {
+                      e.stopPropagation()
+                      saveSchedule({ ...schedule, enabled: !schedule.enabled }, schedule.enabled ? '已停用' : '已启用')
+                    }}
+                    className={`btn btn-sm ${schedule.enabled ? 'btn-secondary' : 'btn-primary'}`}
+                  >
+                    {schedule.enabled ? '停用' : '启用'}
+                  </button>
+                  <button
+                    onClick={(e) => { e.stopPropagation(); handleDelete(schedule.id!); }}
+                    className="btn btn-secondary btn-sm"
+                    title="删除定时扫描"
+                  >
+                    <Trash2 className="w-4 h-4" />
+                  </button>
+                </div>
+
+                {expanded.includes(schedule.id!) && (
+                  <div className="border-t border-dark-700 p-4 space-y-4">
+                    {/* 执行设置 */}
+                    <div className="flex flex-wrap items-center gap-4">
+                      <label className="text-sm text-dark-400">名称</label>
+                      <input
+                        type="text"
+                        value={current.name}
+                        onChange={(e) => updateEditing(schedule, { name: e.target.value })}
+                        className="w-48"
+                      />
+                      {schedule.cron ? (
+                        <>
+                          <label className="text-sm text-dark-400">cron</label>
+                          <input
+                            type="text"
+                            value={current.cron}
+                            onChange={(e) => updateEditing(schedule, { cron: e.target.value })}
+                            className="w-40 font-mono text-sm"
+                          />
+                        </>
+                      ) : (
+                        <>
+                          <label className="text-sm text-dark-400">间隔（分钟）</label>
+                          <input
+                            type="number"
+                            min={1}
+                            value={current.interval}
+                            onChange={(e) => updateEditing(schedule, { interval: parseInt(e.target.value) || 1 })}
+                            className="w-24"
+                          />
+                        </>
+                      )}
+                      <select
+                        value={current.missedPolicy}
+                        onChange={(e) => updateEditing(schedule, { missedPolicy: e.target.value as 'skip' | 'run-once' })}
+                      >
+                        <option value="skip">错过时跳过</option>
+                        <option value="run-once">错过时补执行一次</option>
+                      </select>
+                      {draft && (
+                        <button
+                          onClick={() => saveSchedule(draft, '定时扫描已更新')}
+                          className="btn btn-primary btn-sm"
+                        >
+                          <Save className="w-4 h-4" />
+                          保存
+                        </button>
+                      )}
+                    </div>
+
+                    {/* 扫描目标 */}
+                    <div className="text-xs text-dark-400 space-y-1">
+                      <div>目标: {schedule.request.targets.join(', ') || '-'}</div>
+                      {schedule.request.targetsFile && <div>目标文件: {schedule.request.targetsFile}</div>}
+                    </div>
+
+                    {/* 执行记录 */}
+                    <div>
+                      <h3 className="text-sm text-white mb-2">执行记录</h3>
+                      {(schedule.history || []).length === 0 ? (
+                        <p className="text-xs text-dark-500">尚未执行</p>
+                      ) : (
+                        <div className="space-y-1">
+                          {schedule.history!.map((run, i) => (
+                            <div key={i} className="flex items-center gap-3 text-xs">
+                              {run.skipped ? (
+                                <SkipForward className="w-4 h-4 text-dark-500" />
+                              ) : run.error ? (
+                                <XCircle className="w-4 h-4 text-red-400" />
+                              ) : (
+                                <CheckCircle className="w-4 h-4 text-green-400" />
+                              )}
+                              <span className="text-dark-300">{formatTime(run.time)}</span>
+                              {run.skipped && <span className="text-dark-500">应用未运行，已跳过</span>}
+                              {run.error && <span className="text-red-400 truncate">{run.error}</span>}
+                              {run.scanId && !run.error && (
+                                onViewResult ? (
+                                  <button
+                                    onClick={() => onViewResult(run.scanId!)}
+                                    className="text-cyber-400 hover:underline font-mono"
+                                  >
+                                    {run.scanId}
+                                  </button>
+                                ) : (
+                                  <span className="text-dark-400 font-mono">{run.scanId}</span>
+                                )
+                              )}
+                            </div>
+                          ))}
+                        </div>
+                      )}
+                    </div>
+                  </div>
+                )}
+              </div>
+            )
+          })}
+        </div>
+      )}
+    </div>
+  )
+}
+
+export default Schedules
@@ -17,6 +17,7 @@ function Settings() {
     timeout: 30,
     rateLimit: 100,
     bulkSize: 25,
+    maxConcurrentScans: 2,
     templatesDir: '',
     proxyUrl: '',
     headless: false,
@@ -98,6 +99,14 @@ function Settings() {
           min: 1,
           max: 100,
         },
+        {
+          key: 'maxConcurrentScans',
+          label: '同时运行的扫描数',
+          description: '超出的扫描任务排队等待',
+          type: 'number',
+          min: 1,
+          max: 20,
+        },
       ],
     },
     {
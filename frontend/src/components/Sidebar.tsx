@@ -6,7 +6,8 @@ import {
   ClipboardList, 
   Settings,
   Bug,
-  Wrench
+  Wrench,
+  CalendarClock
 } from 'lucide-react'
 import { ViewType, Stats } from '../types'
 import logoImage from '../assets/logo.jpg'
@@ -27,6 +28,7 @@ const navItems: NavItem[] = [
   { id: 'dashboard', label: '仪表盘', icon: LayoutDashboard },
   { id: 'templates', label: 'POC 模板', icon: FileCode2 },
   { id: 'scanner', label: '扫描器', icon: Radar },
+  { id: 'schedules', label: '定时扫描', icon: CalendarClock },
   { id: 'results', label: '扫描结果', icon: ClipboardList },
   { id: 'tools', label: '编码工具', icon: Wrench },
   { id: 'settings', label: '设置', icon: Settings },
@@ -9,6 +9,7 @@ export interface POCTemplate {
   category: string;
   content: string;
   filePath: string;
+  workflow?: boolean;
   createdAt: string;
   updatedAt: string;
 }
@@ -16,8 +17,11 @@ export interface POCTemplate {
 export interface ScanRequest {
   name?: string;
   targets: string[];
+  targetsFile?: string;
   templateIds: string[];
+  workflowIds?: string[];
   options: ScanOptions;
+  priority?: number;
 }
 
 export interface ScanOptions {
@@ -26,12 +30,37 @@ export interface ScanOptions {
   rateLimit: number;
   bulkSize: number;
   headless: boolean;
+  keepAlive?: boolean;
+  proxyUrl?: string;
+}
+
+export interface Schedule {
+  id?: string;
+  name: string;
+  enabled: boolean;
+  cron?: string;
+  interval?: number;
+  missedPolicy: 'skip' | 'run-once';
+  request: ScanRequest;
+  nextRun?: string;
+  lastRun?: string;
+  history?: ScheduleRun[];
+  createdAt?: string;
+  updatedAt?: string;
+}
+
+export interface ScheduleRun {
+  time: string;
+  ranAt?: string;
+  scanId?: string;
+  skipped?: boolean;
+  error?: string;
 }
 
 export interface ScanStatus {
   id: string;
   name?: string;
-  status: 'pending' | 'running' | 'completed' | 'failed' | 'stopped';
+  status: 'pending' | 'queued' | 'running' | 'paused' | 'completed' | 'failed' | 'stopped';
   progress: number;
   total: number;
   completed: number;
@@ -40,7 +69,15 @@ export interface ScanStatus {
   completedAt?: string;
   error?: string;
   targets: string[];
+  targetsFile?: string;
+  targetCount?: number;
   templateIds: string[];
+  workflowIds?: string[];
+  requestsSaved?: number;
+  errors?: number;
+  resumable?: boolean;
+  priority?: number;
+  queuePosition?: number;
 }
 
 export interface ScanResult {
@@ -51,11 +88,24 @@ export interface ScanResult {
   severity: string;
   host: string;
   matched: string;
-  extractedData?: Record<string, string>;
+  matcherNames?: string[];
+  extractedData?: Record<string, string[]>;
   error?: string;
   timestamp: string;
   request?: string;
   response?: string;
+  filePath?: string;
+  lines?: number[];
+}
+
+// 两次扫描的对比结果
+export interface ScanDiff {
+  baseScanId: string;
+  newScanId: string;
+  new: ScanResult[];
+  fixed: ScanResult[];
+  persisting: ScanResult[];
+  unverified: ScanResult[];
 }
 
 export interface Stats {
@@ -74,10 +124,23 @@ export interface Settings {
   timeout: number;
   rateLimit: number;
   bulkSize: number;
+  maxConcurrentScans?: number;
   templatesDir: string;
   proxyUrl?: string;
   headless: boolean;
+  oast?: OASTSettings;
+}
+
+export interface OASTSettings {
+  enabled: boolean;
+  listenIp?: string;
+  publicHost?: string;
+  domain?: string;
+  dnsPort?: number;
+  httpPort?: number;
+  smtpPort?: number;
+  cooldown?: number;
 }
 
-export type ViewType = 'dashboard' | 'templates' | 'scanner' | 'results' | 'tools' | 'settings';
+export type ViewType = 'dashboard' | 'templates' | 'scanner' | 'schedules' | 'results' | 'tools' | 'settings';
 
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // POCTemplate POC模板结构
 type POCTemplate struct {
@@ -14,61 +17,153 @@ type POCTemplate struct {
 	Category    string    `json:"category"`
 	Content     string    `json:"content"`
 	FilePath    string    `json:"filePath"`
+	Workflow    bool      `json:"workflow,omitempty"` // 工作流文件（包含 workflows 块）
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
+// Workflow 工作流：先执行条件模板（如指纹识别），命中后才对同一目标执行子模板
+type Workflow struct {
+	ID    string         `json:"id"`
+	Name  string         `json:"name"`
+	Steps []WorkflowStep `json:"steps"`
+}
+
+// WorkflowStep 工作流中的一步：Templates 中任一模板命中后执行 Subtemplates，
+// 命中的 matcher 名称满足 Matchers 中的分支时执行该分支的子模板
+type WorkflowStep struct {
+	Templates    []POCTemplate     `json:"templates"` // template / tags 选中的模板
+	Subtemplates []WorkflowStep    `json:"subtemplates,omitempty"`
+	Matchers     []WorkflowMatcher `json:"matchers,omitempty"`
+}
+
+// WorkflowMatcher 按命名 matcher 执行的分支
+type WorkflowMatcher struct {
+	Names        []string       `json:"names"`
+	Condition    string         `json:"condition,omitempty"` // or（默认）, and
+	Subtemplates []WorkflowStep `json:"subtemplates"`
+}
+
 // ScanRequest 扫描请求
 type ScanRequest struct {
 	Targets     []string    `json:"targets"`
+	TargetsFile string      `json:"targetsFile,omitempty"` // 目标列表文件（每行一个目标，扫描时逐行读取）
 	TemplateIDs []string    `json:"templateIds"`
+	WorkflowIDs []string    `json:"workflowIds,omitempty"`
 	Options     ScanOptions `json:"options"`
-	Name        string      `json:"name,omitempty"` // 任务名称
+	Name        string      `json:"name,omitempty"`     // 任务名称
+	Priority    int         `json:"priority,omitempty"` // 排队优先级，越大越先执行
+}
+
+// Schedule 定时扫描：按 cron 表达式或固定间隔重复执行保存的扫描请求
+type Schedule struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Enabled      bool          `json:"enabled"`
+	Cron         string        `json:"cron,omitempty"`     // 5 段 cron 表达式（分 时 日 月 周），与 Interval 二选一
+	Interval     int           `json:"interval,omitempty"` // 执行间隔（分钟）
+	MissedPolicy string        `json:"missedPolicy"`       // 应用关闭期间错过的执行：skip（默认，跳过）, run-once（启动后补执行一次）
+	Request      ScanRequest   `json:"request"`
+	NextRun      time.Time     `json:"nextRun"`
+	LastRun      time.Time     `json:"lastRun,omitempty"`
+	History      []ScheduleRun `json:"history,omitempty"` // 最近的执行记录（新的在前）
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+// ScheduleRun 定时扫描的一次执行记录
+type ScheduleRun struct {
+	Time    time.Time `json:"time"`              // 计划执行时间
+	RanAt   time.Time `json:"ranAt,omitempty"`   // 实际执行时间，跳过时为空
+	ScanID  string    `json:"scanId,omitempty"`  // 启动的扫描
+	Skipped bool      `json:"skipped,omitempty"` // 错过后按策略跳过
+	Error   string    `json:"error,omitempty"`
 }
 
 // ScanOptions 扫描选项
 type ScanOptions struct {
-	Concurrency     int    `json:"concurrency"`
-	Timeout         int    `json:"timeout"`
-	RateLimit       int    `json:"rateLimit"`
-	BulkSize        int    `json:"bulkSize"`
-	Headless        bool   `json:"headless"`
-	MaxResponseSize int    `json:"maxResponseSize"` // 响应体最大读取大小（字节），0=默认1MB
-	RetryCount      int    `json:"retryCount"`      // 失败重试次数，0=不重试
-	AllowPrivate    bool   `json:"allowPrivate"`     // 是否允许扫描内网地址
-	ProxyURL        string `json:"proxyUrl,omitempty"`
+	Concurrency     int      `json:"concurrency"`
+	Timeout         int      `json:"timeout"`
+	RateLimit       int      `json:"rateLimit"`
+	BulkSize        int      `json:"bulkSize"`
+	Headless        bool     `json:"headless"`
+	MaxResponseSize int      `json:"maxResponseSize"` // 响应体最大读取大小（字节），0=默认1MB
+	RetryCount      int      `json:"retryCount"`      // 失败重试次数，0=不重试
+	AllowPrivate    bool     `json:"allowPrivate"`    // 是否允许扫描内网地址
+	ProxyURL        string   `json:"proxyUrl,omitempty"`
+	KeepAlive       bool     `json:"keepAlive,omitempty"` // 复用 HTTP 连接（默认每个请求使用新连接），模板请求块的 keep-alive 优先
+	Resolvers       []string `json:"resolvers,omitempty"` // DNS 模板使用的解析服务器（ip 或 ip:port），为空时使用操作系统配置的解析服务器
 }
 
 // ScanStatus 扫描状态
 type ScanStatus struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name,omitempty"` // 任务名称
-	Status       string    `json:"status"`         // pending, running, completed, failed, stopped
-	Progress     float64   `json:"progress"`
-	Total        int       `json:"total"`
-	Completed    int       `json:"completed"`
-	Found        int       `json:"found"`
-	StartedAt    time.Time `json:"startedAt"`
-	CompletedAt  time.Time `json:"completedAt,omitempty"`
-	Error        string    `json:"error,omitempty"`
-	Targets      []string  `json:"targets"`
-	TemplateIDs  []string  `json:"templateIds"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name,omitempty"` // 任务名称
+	Status        string    `json:"status"`         // pending, queued, running, paused, completed, failed, stopped
+	Progress      float64   `json:"progress"`
+	Total         int       `json:"total"`
+	Completed     int       `json:"completed"`
+	Found         int       `json:"found"`
+	StartedAt     time.Time `json:"startedAt"`
+	CompletedAt   time.Time `json:"completedAt,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Targets       []string  `json:"targets"`
+	TargetsFile   string    `json:"targetsFile,omitempty"` // 目标列表文件
+	TargetCount   int       `json:"targetCount"`           // 目标总数（包含目标文件中的目标）
+	TemplateIDs   []string  `json:"templateIds"`
+	WorkflowIDs   []string  `json:"workflowIds,omitempty"`
+	RequestsSaved int       `json:"requestsSaved,omitempty"` // 模板聚类节省的请求数
+	Errors        int       `json:"errors,omitempty"`        // 请求失败的 目标 × 模板 数（每个目标只保存一条错误结果）
+	Resumable     bool      `json:"resumable,omitempty"`     // 有断点，可以继续扫描（暂停、停止或中断的扫描）
+	Priority      int       `json:"priority,omitempty"`      // 排队优先级，越大越先执行
+	QueuePosition int       `json:"queuePosition,omitempty"` // 在等待队列中的位置（从 1 开始），不在队列中时为 0
 }
 
 // ScanResult 扫描结果
 type ScanResult struct {
-	ID            string            `json:"id"`
-	ScanID        string            `json:"scanId"`
-	TemplateID    string            `json:"templateId"`
-	TemplateName  string            `json:"templateName"`
-	Severity      string            `json:"severity"`
-	Host          string            `json:"host"`
-	Matched       string            `json:"matched"`
-	ExtractedData map[string]string `json:"extractedData,omitempty"`
-	Error         string            `json:"error,omitempty"`    // 请求失败原因
-	Timestamp     time.Time         `json:"timestamp"`
-	Request       string            `json:"request,omitempty"`
-	Response      string            `json:"response,omitempty"`
+	ID            string                     `json:"id"`
+	ScanID        string                     `json:"scanId"`
+	TemplateID    string                     `json:"templateId"`
+	TemplateName  string                     `json:"templateName"`
+	Severity      string                     `json:"severity"`
+	Host          string                     `json:"host"`
+	Matched       string                     `json:"matched"`
+	MatcherNames  []string                   `json:"matcherNames,omitempty"` // 命中的命名 matcher（工作流按此判断分支）
+	ExtractedData map[string]ExtractedValues `json:"extractedData,omitempty"`
+	Error         string                     `json:"error,omitempty"` // 请求失败原因
+	Timestamp     time.Time                  `json:"timestamp"`
+	Request       string                     `json:"request,omitempty"`
+	Response      string                     `json:"response,omitempty"`
+	FilePath      string                     `json:"filePath,omitempty"` // file 模板命中的文件（压缩包内为 包路径!/文件路径）
+	Lines         []int                      `json:"lines,omitempty"`    // file 模板命中内容所在的行号
+}
+
+// ScanDiff 两次扫描的结果对比，发现按模板 ID + 规范化的主机 + 命中位置匹配
+type ScanDiff struct {
+	BaseScanID string       `json:"baseScanId"`
+	NewScanID  string       `json:"newScanId"`
+	New        []ScanResult `json:"new"`        // 只在新扫描中出现
+	Fixed      []ScanResult `json:"fixed"`      // 只在基准扫描中出现，且新扫描执行了该模板并收到了目标的响应（只能确认新扫描开始时已有的发现）
+	Persisting []ScanResult `json:"persisting"` // 两次扫描都出现（取新扫描中的结果）
+	Unverified []ScanResult `json:"unverified"` // 只在基准扫描中出现，但新扫描未执行该模板、请求失败或没有收到响应，无法确认已修复
+}
+
+// ExtractedValues 单个提取器提取到的全部值
+type ExtractedValues []string
+
+// UnmarshalJSON 兼容旧版本保存的单个字符串
+func (v *ExtractedValues) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*v = ExtractedValues{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*v = list
+	return nil
 }
 
 // Stats 统计信息
@@ -85,13 +180,28 @@ type Stats struct {
 
 // Settings 应用设置
 type Settings struct {
-	Concurrency  int    `json:"concurrency"`
-	Timeout      int    `json:"timeout"`
-	RateLimit    int    `json:"rateLimit"`
-	BulkSize     int    `json:"bulkSize"`
-	TemplatesDir string `json:"templatesDir"`
-	ProxyURL     string `json:"proxyUrl,omitempty"`
-	Headless     bool   `json:"headless"`
+	Concurrency  int          `json:"concurrency"`
+	Timeout      int          `json:"timeout"`
+	RateLimit    int          `json:"rateLimit"`
+	BulkSize     int          `json:"bulkSize"`
+	TemplatesDir string       `json:"templatesDir"`
+	ProxyURL     string       `json:"proxyUrl,omitempty"`
+	Headless     bool         `json:"headless"`
+	OAST         OASTSettings `json:"oast"`
+	// MaxConcurrentScans 同时运行的扫描数上限，超出的扫描排队等待，0 使用默认值
+	MaxConcurrentScans int `json:"maxConcurrentScans,omitempty"`
+}
+
+// OASTSettings 内置带外交互（OAST）服务设置，完全离线运行
+type OASTSettings struct {
+	Enabled    bool   `json:"enabled"`
+	ListenIP   string `json:"listenIp,omitempty"`   // 监听地址，默认 0.0.0.0
+	PublicHost string `json:"publicHost,omitempty"` // 目标可访问到的本机地址（IP），DNS 解析结果也指向它
+	Domain     string `json:"domain,omitempty"`     // 关联域名（NS 指向本机）；为空时 interactsh-url 使用 PublicHost/关联ID 形式
+	DNSPort    int    `json:"dnsPort,omitempty"`    // 0 表示不启用
+	HTTPPort   int    `json:"httpPort,omitempty"`   // 0 表示不启用
+	SMTPPort   int    `json:"smtpPort,omitempty"`   // 0 表示不启用
+	Cooldown   int    `json:"cooldown,omitempty"`   // 请求发出后等待交互的时间（秒），默认 5
 }
 
 
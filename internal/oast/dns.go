@@ -0,0 +1,145 @@
+package oast
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startDNS 启动 UDP / TCP DNS 监听
+func (s *Server) startDNS(addr string) error {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("DNS 监听失败: %v", err)
+	}
+	s.addCloser(pc.Close)
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("DNS(TCP) 监听失败: %v", err)
+	}
+	s.addCloser(ln.Close)
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, remote, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := s.handleDNS(buf[:n], remote); resp != nil {
+				pc.WriteTo(resp, remote)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveDNSConn(conn)
+		}
+	}()
+	return nil
+}
+
+// serveDNSConn 处理 TCP DNS 连接（2 字节长度前缀）
+func (s *Server) serveDNSConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	for {
+		var size uint16
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return
+		}
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		resp := s.handleDNS(msg, conn.RemoteAddr())
+		if resp == nil {
+			return
+		}
+		out := make([]byte, 2+len(resp))
+		binary.BigEndian.PutUint16(out, uint16(len(resp)))
+		copy(out[2:], resp)
+		if _, err := conn.Write(out); err != nil {
+			return
+		}
+	}
+}
+
+// handleDNS 解析查询、记录交互并生成应答
+// 关联域名下的 A 记录指向 answerIP，其余类型返回空应答
+func (s *Server) handleDNS(packet []byte, remote net.Addr) []byte {
+	var p dnsmessage.Parser
+	header, err := p.Start(packet)
+	if err != nil {
+		return nil
+	}
+	question, err := p.Question()
+	if err != nil {
+		return nil
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(question.Name.String()), ".")
+	qtype := strings.TrimPrefix(question.Type.String(), "Type")
+	s.record(Interaction{
+		Protocol:      "dns",
+		FullID:        name,
+		QType:         qtype,
+		RawRequest:    fmt.Sprintf(";; QUESTION\n%s.\tIN\t%s\n;; ID %d", name, qtype, header.ID),
+		RemoteAddress: remoteIP(remote.String()),
+	}, name)
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: false,
+	})
+	builder.EnableCompression()
+	if err := builder.StartQuestions(); err != nil {
+		return nil
+	}
+	builder.Question(question)
+	if err := builder.StartAnswers(); err != nil {
+		return nil
+	}
+
+	inZone := s.cfg.Domain == "" || name == s.cfg.Domain || strings.HasSuffix(name, "."+s.cfg.Domain)
+	ip := s.answerIP()
+	if inZone && question.Type == dnsmessage.TypeA && ip.To4() != nil {
+		var a dnsmessage.AResource
+		copy(a.A[:], ip.To4())
+		builder.AResource(dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}, a)
+	}
+	if inZone && question.Type == dnsmessage.TypeAAAA && ip.To4() == nil {
+		var aaaa dnsmessage.AAAAResource
+		copy(aaaa.AAAA[:], ip.To16())
+		builder.AAAAResource(dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}, aaaa)
+	}
+
+	resp, err := builder.Finish()
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+// remoteIP 取出 "ip:port" 中的 IP
+func remoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,56 @@
+package oast
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"time"
+)
+
+// startHTTP 启动 HTTP 监听（关联 ID 可以出现在 Host 头或路径中）
+func (s *Server) startHTTP(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("HTTP 监听失败: %v", err)
+	}
+	srv := &http.Server{
+		Handler:           http.HandlerFunc(s.handleHTTP),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.addCloser(srv.Close)
+	go srv.Serve(ln)
+	return nil
+}
+
+// handleHTTP 记录 HTTP 交互，响应体为反转后的关联 ID（与 interactsh 行为一致）
+func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	raw, _ := httputil.DumpRequest(r, true)
+	id := s.correlate(r.Host, r.URL.Path)
+
+	body := "<html><head></head><body>" + reverse(id) + "</body></html>"
+	w.Header().Set("Server", "oast")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, body)
+
+	if id == "" {
+		return
+	}
+	s.record(Interaction{
+		Protocol:      "http",
+		FullID:        r.Host + r.URL.Path,
+		RawRequest:    string(raw),
+		RawResponse:   "HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\n\r\n" + body,
+		RemoteAddress: remoteIP(r.RemoteAddr),
+	}, id)
+}
+
+// reverse 反转字符串
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
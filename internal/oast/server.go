@@ -0,0 +1,289 @@
+// Package oast 内置的带外交互（OAST）服务
+// 在本机监听 DNS / HTTP / SMTP，为每个请求生成唯一的关联 ID（{{interactsh-url}}），
+// 记录目标回连产生的交互，供盲 SSRF、XXE、Log4Shell、RCE 等模板匹配，无需访问外网
+package oast
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+const (
+	prefixLen       = 8  // 扫描级关联前缀长度
+	nonceLen        = 12 // 请求级随机部分长度
+	idLen           = prefixLen + nonceLen
+	DefaultCooldown = 5 * time.Second  // 请求发出后等待交互的默认时间
+	DefaultEviction = 30 * time.Minute // 关联 ID 的保留时间（期间到达的交互仍会匹配回模板）
+	maxInteractions = 50               // 单个关联 ID 保留的最大交互数
+)
+
+// idAlphabet 关联 ID 字符集（DNS 标签安全、大小写不敏感）
+const idAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// Interaction 一次带外交互
+type Interaction struct {
+	Protocol      string    `json:"protocol"` // dns, http, smtp
+	UniqueID      string    `json:"uniqueId"` // 关联 ID
+	FullID        string    `json:"fullId"`   // 完整的请求域名 / 路径
+	QType         string    `json:"qtype,omitempty"`
+	RawRequest    string    `json:"rawRequest"`
+	RawResponse   string    `json:"rawResponse,omitempty"`
+	RemoteAddress string    `json:"remoteAddress"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// session 单个关联 ID 的状态
+type session struct {
+	owner        string // 所属扫描 ID
+	created      time.Time
+	interactions []Interaction
+	watcher      func(Interaction) // 等待期结束后到达的交互回调
+}
+
+// Server 带外交互服务
+type Server struct {
+	cfg      models.OASTSettings
+	sessions map[string]*session
+	prefixes map[string]string // 扫描 ID -> 关联前缀（扫描的关联 ID 全部过期后清除）
+	closers  []func() error
+	mu       sync.Mutex
+	done     chan struct{}
+}
+
+// NewServer 创建带外交互服务（调用 Start 后开始监听）
+func NewServer(cfg models.OASTSettings) *Server {
+	if cfg.ListenIP == "" {
+		cfg.ListenIP = "0.0.0.0"
+	}
+	cfg.Domain = strings.Trim(strings.ToLower(cfg.Domain), ".")
+	return &Server{
+		cfg:      cfg,
+		sessions: make(map[string]*session),
+		prefixes: make(map[string]string),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start 启动已配置端口的监听（任一监听失败时关闭已启动的监听并返回错误）
+func (s *Server) Start() error {
+	if s.cfg.DNSPort == 0 && s.cfg.HTTPPort == 0 && s.cfg.SMTPPort == 0 {
+		return fmt.Errorf("未配置任何 OAST 监听端口")
+	}
+	if s.cfg.PublicHost == "" && s.cfg.Domain == "" {
+		return fmt.Errorf("OAST 需要配置对外地址或关联域名")
+	}
+
+	starters := []struct {
+		port  int
+		start func(addr string) error
+	}{
+		{s.cfg.DNSPort, s.startDNS},
+		{s.cfg.HTTPPort, s.startHTTP},
+		{s.cfg.SMTPPort, s.startSMTP},
+	}
+	for _, st := range starters {
+		if st.port == 0 {
+			continue
+		}
+		if err := st.start(net.JoinHostPort(s.cfg.ListenIP, strconv.Itoa(st.port))); err != nil {
+			s.Close()
+			return err
+		}
+	}
+
+	go s.evictLoop()
+	return nil
+}
+
+// Close 关闭全部监听
+func (s *Server) Close() error {
+	s.mu.Lock()
+	closers := s.closers
+	s.closers = nil
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	s.mu.Unlock()
+
+	for _, c := range closers {
+		c()
+	}
+	return nil
+}
+
+// Cooldown 请求发出后等待交互的时间
+func (s *Server) Cooldown() time.Duration {
+	if s.cfg.Cooldown > 0 {
+		return time.Duration(s.cfg.Cooldown) * time.Second
+	}
+	return DefaultCooldown
+}
+
+// addCloser 登记监听的关闭函数
+func (s *Server) addCloser(c func() error) {
+	s.mu.Lock()
+	s.closers = append(s.closers, c)
+	s.mu.Unlock()
+}
+
+// NewURL 为扫描 owner 生成新的关联 ID 及对应的 interactsh-url
+// 配置了关联域名时为 <id>.<domain>，否则为 <publicHost>[:port]/<id>
+func (s *Server) NewURL(owner string) (id, url string) {
+	s.mu.Lock()
+	prefix, ok := s.prefixes[owner]
+	if !ok {
+		prefix = randomID(prefixLen)
+		s.prefixes[owner] = prefix
+	}
+	id = prefix + randomID(nonceLen)
+	s.sessions[id] = &session{owner: owner, created: time.Now()}
+	s.mu.Unlock()
+
+	if s.cfg.Domain != "" {
+		return id, id + "." + s.cfg.Domain
+	}
+	host := s.cfg.PublicHost
+	if s.cfg.HTTPPort != 0 && s.cfg.HTTPPort != 80 {
+		host = net.JoinHostPort(host, strconv.Itoa(s.cfg.HTTPPort))
+	}
+	return id, host + "/" + id
+}
+
+// Interactions 返回关联 ID 已收到的交互
+func (s *Server) Interactions(id string) []Interaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+	return append([]Interaction(nil), sess.interactions...)
+}
+
+// Watch 为关联 ID 登记回调：先回放已收到的交互，之后到达的交互（保留期内）逐个回调
+func (s *Server) Watch(ids []string, fn func(Interaction)) {
+	var replay []Interaction
+	s.mu.Lock()
+	for _, id := range ids {
+		if sess, ok := s.sessions[id]; ok {
+			sess.watcher = fn
+			replay = append(replay, sess.interactions...)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, it := range replay {
+		fn(it)
+	}
+}
+
+// record 记录交互（根据内容中出现的关联 ID 归属到对应会话）
+func (s *Server) record(it Interaction, candidates ...string) {
+	id := s.correlate(candidates...)
+	if id == "" {
+		return
+	}
+	it.UniqueID = id
+	if it.Timestamp.IsZero() {
+		it.Timestamp = time.Now()
+	}
+
+	s.mu.Lock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	if len(sess.interactions) < maxInteractions {
+		sess.interactions = append(sess.interactions, it)
+	}
+	watcher := sess.watcher
+	s.mu.Unlock()
+
+	if watcher != nil {
+		go watcher(it)
+	}
+}
+
+// correlate 从域名、路径、邮件地址等文本中找出已登记的关联 ID
+func (s *Server) correlate(candidates ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range candidates {
+		for _, token := range strings.FieldsFunc(strings.ToLower(c), func(r rune) bool {
+			return !strings.ContainsRune(idAlphabet, r)
+		}) {
+			// 关联 ID 可能与其他字符相连（如 x<id>），按固定长度滑动查找
+			for i := 0; i+idLen <= len(token); i++ {
+				if _, ok := s.sessions[token[i:i+idLen]]; ok {
+					return token[i : i+idLen]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// evictLoop 定期清理过期的关联 ID
+func (s *Server) evictLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.evict(time.Now().Add(-DefaultEviction))
+		}
+	}
+}
+
+// evict 清理 cutoff 之前创建的关联 ID，以及已没有关联 ID 的扫描前缀
+// 扫描结束后前缀随最后一个关联 ID 过期，之后该扫描继续执行时会生成新的前缀
+func (s *Server) evict(cutoff time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owners := make(map[string]bool, len(s.prefixes))
+	for id, sess := range s.sessions {
+		if sess.created.Before(cutoff) {
+			delete(s.sessions, id)
+			continue
+		}
+		owners[sess.owner] = true
+	}
+	for owner := range s.prefixes {
+		if !owners[owner] {
+			delete(s.prefixes, owner)
+		}
+	}
+}
+
+// answerIP DNS 解析返回的地址
+func (s *Server) answerIP() net.IP {
+	if ip := net.ParseIP(s.cfg.PublicHost); ip != nil {
+		return ip
+	}
+	if ip := net.ParseIP(s.cfg.ListenIP); ip != nil && !ip.IsUnspecified() {
+		return ip
+	}
+	return net.IPv4(127, 0, 0, 1)
+}
+
+// randomID 生成随机关联 ID
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	for i := range b {
+		b[i] = idAlphabet[int(b[i])%len(idAlphabet)]
+	}
+	return string(b)
+}
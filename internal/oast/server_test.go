@@ -0,0 +1,46 @@
+package oast
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+func TestEvictExpiresPrefixes(t *testing.T) {
+	s := NewServer(models.OASTSettings{Domain: "oast.test"})
+	oldID, _ := s.NewURL("old-scan")
+	liveID, _ := s.NewURL("live-scan")
+
+	// old-scan 的关联 ID 全部过期，live-scan 仍有未过期的关联 ID
+	s.mu.Lock()
+	s.sessions[oldID].created = time.Now().Add(-2 * DefaultEviction)
+	s.mu.Unlock()
+	s.evict(time.Now().Add(-DefaultEviction))
+
+	s.mu.Lock()
+	_, oldSession := s.sessions[oldID]
+	_, oldPrefix := s.prefixes["old-scan"]
+	livePrefix := s.prefixes["live-scan"]
+	s.mu.Unlock()
+	if oldSession || oldPrefix {
+		t.Errorf("old-scan not evicted: session = %v, prefix = %v", oldSession, oldPrefix)
+	}
+	if livePrefix == "" || !strings.HasPrefix(liveID, livePrefix) {
+		t.Errorf("live-scan prefix = %q, want the prefix of %s", livePrefix, liveID)
+	}
+
+	// 仍在使用的扫描继续沿用原前缀
+	if id, _ := s.NewURL("live-scan"); !strings.HasPrefix(id, livePrefix) {
+		t.Errorf("new id %s does not reuse prefix %s", id, livePrefix)
+	}
+
+	// 全部过期后不再保留任何前缀
+	s.evict(time.Now().Add(time.Minute))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.sessions) != 0 || len(s.prefixes) != 0 {
+		t.Errorf("sessions = %d, prefixes = %d after all expired", len(s.sessions), len(s.prefixes))
+	}
+}
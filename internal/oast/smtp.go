@@ -0,0 +1,107 @@
+package oast
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// maxSMTPData 单封邮件最多保留的内容长度
+const maxSMTPData = 64 * 1024
+
+// startSMTP 启动 SMTP 监听（只实现接收邮件所需的最小命令集）
+func (s *Server) startSMTP(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("SMTP 监听失败: %v", err)
+	}
+	s.addCloser(ln.Close)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveSMTP(conn)
+		}
+	}()
+	return nil
+}
+
+// serveSMTP 处理单个 SMTP 会话，收到 DATA 或会话结束时记录交互
+// 关联 ID 可以出现在 HELO、MAIL FROM、RCPT TO 或邮件内容中
+func (s *Server) serveSMTP(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(60 * time.Second))
+
+	hostname := s.cfg.Domain
+	if hostname == "" {
+		hostname = "oast.local"
+	}
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	var transcript strings.Builder
+	var envelope []string
+	flush := func() {
+		if transcript.Len() == 0 {
+			return
+		}
+		s.record(Interaction{
+			Protocol:      "smtp",
+			FullID:        strings.Join(envelope, " "),
+			RawRequest:    transcript.String(),
+			RemoteAddress: remoteIP(conn.RemoteAddr().String()),
+		}, append(envelope, transcript.String())...)
+		transcript.Reset()
+		envelope = nil
+	}
+	defer flush()
+
+	reply("220 " + hostname + " ESMTP ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		transcript.WriteString(line)
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(cmd, "HELO"), strings.HasPrefix(cmd, "EHLO"):
+			envelope = append(envelope, strings.TrimSpace(line[4:]))
+			reply("250 " + hostname)
+		case strings.HasPrefix(cmd, "MAIL FROM"), strings.HasPrefix(cmd, "RCPT TO"):
+			if idx := strings.Index(line, ":"); idx > 0 {
+				envelope = append(envelope, strings.TrimSpace(line[idx+1:]))
+			}
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimRight(dataLine, "\r\n") == "." {
+					break
+				}
+				if transcript.Len() < maxSMTPData {
+					transcript.WriteString(dataLine)
+				}
+			}
+			reply("250 OK: queued")
+			flush()
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		case cmd == "RSET", cmd == "NOOP":
+			reply("250 OK")
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
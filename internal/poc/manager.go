@@ -36,6 +36,7 @@ type NucleiTemplate struct {
 		Reference   []string `yaml:"reference"`
 		Tags        string   `yaml:"tags"`
 	} `yaml:"info"`
+	Workflows []interface{} `yaml:"workflows"`
 }
 
 // NewManager 创建新的Manager实例
@@ -232,6 +233,7 @@ func (m *Manager) parseYAMLContent(content string) (*models.POCTemplate, error)
 		Description: nt.Info.Description,
 		Reference:   nt.Info.Reference,
 		Content:     content,
+		Workflow:    len(nt.Workflows) > 0,
 	}
 
 	if nt.Info.Tags != "" {
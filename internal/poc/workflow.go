@@ -0,0 +1,215 @@
+package poc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"nuclei-poc-manager/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxWorkflowDepth 子模板最大嵌套层数（防止配置错误导致无限递归）
+const maxWorkflowDepth = 10
+
+// stringList YAML 中既可以写成单个字符串也可以写成列表的字段
+type stringList []string
+
+// UnmarshalYAML 同时接受 "a" 和 ["a", "b"]
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = stringList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// nucleiWorkflow Nuclei 工作流文件结构
+type nucleiWorkflow struct {
+	ID   string `yaml:"id"`
+	Info struct {
+		Name string `yaml:"name"`
+	} `yaml:"info"`
+	Workflows []nucleiWorkflowStep `yaml:"workflows"`
+}
+
+// nucleiWorkflowStep 工作流中的一步（template 与 tags 二选一）
+type nucleiWorkflowStep struct {
+	Template     string                  `yaml:"template"`
+	Tags         stringList              `yaml:"tags"`
+	Matchers     []nucleiWorkflowMatcher `yaml:"matchers"`
+	Subtemplates []nucleiWorkflowStep    `yaml:"subtemplates"`
+}
+
+// nucleiWorkflowMatcher 按命名 matcher 执行子模板的分支
+type nucleiWorkflowMatcher struct {
+	Name         stringList           `yaml:"name"`
+	Condition    string               `yaml:"condition"`
+	Subtemplates []nucleiWorkflowStep `yaml:"subtemplates"`
+}
+
+// ResolveWorkflow 解析工作流文件，将其中引用的模板路径、模板 ID 和标签解析为具体模板
+func (m *Manager) ResolveWorkflow(id string) (*models.Workflow, error) {
+	t, err := m.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !t.Workflow {
+		return nil, fmt.Errorf("模板不是工作流: %s", id)
+	}
+
+	var nw nucleiWorkflow
+	if err := yaml.Unmarshal([]byte(t.Content), &nw); err != nil {
+		return nil, fmt.Errorf("工作流解析失败: %v", err)
+	}
+
+	wf := &models.Workflow{ID: t.ID, Name: t.Name}
+	steps, err := m.resolveWorkflowSteps(nw.Workflows, filepath.Dir(t.FilePath), 0)
+	if err != nil {
+		return nil, fmt.Errorf("工作流 %s: %v", id, err)
+	}
+	wf.Steps = steps
+	if len(wf.Steps) == 0 {
+		return nil, fmt.Errorf("工作流 %s 没有可执行的模板", id)
+	}
+	return wf, nil
+}
+
+// resolveWorkflowSteps 递归解析工作流步骤
+func (m *Manager) resolveWorkflowSteps(nsteps []nucleiWorkflowStep, baseDir string, depth int) ([]models.WorkflowStep, error) {
+	if depth > maxWorkflowDepth {
+		return nil, fmt.Errorf("子模板嵌套超过 %d 层", maxWorkflowDepth)
+	}
+
+	var steps []models.WorkflowStep
+	for _, ns := range nsteps {
+		var step models.WorkflowStep
+		if ns.Template != "" {
+			t, err := m.findWorkflowTemplate(ns.Template, baseDir)
+			if err != nil {
+				return nil, err
+			}
+			step.Templates = append(step.Templates, *t)
+		}
+		if len(ns.Tags) > 0 {
+			step.Templates = append(step.Templates, m.templatesByTags(ns.Tags)...)
+		}
+		if len(step.Templates) == 0 {
+			// 标签没有选中任何模板时跳过该步骤
+			continue
+		}
+
+		subs, err := m.resolveWorkflowSteps(ns.Subtemplates, baseDir, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		step.Subtemplates = subs
+		for _, nm := range ns.Matchers {
+			subs, err := m.resolveWorkflowSteps(nm.Subtemplates, baseDir, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			step.Matchers = append(step.Matchers, models.WorkflowMatcher{
+				Names:        nm.Name,
+				Condition:    strings.ToLower(nm.Condition),
+				Subtemplates: subs,
+			})
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// findWorkflowTemplate 查找工作流引用的模板：
+// 依次尝试模板目录下的相对路径、工作流所在目录下的相对路径、绝对路径，最后按模板 ID 查找
+func (m *Manager) findWorkflowTemplate(ref, baseDir string) (*models.POCTemplate, error) {
+	candidates := []string{filepath.Join(m.templatesDir, ref), filepath.Join(baseDir, ref)}
+	if filepath.IsAbs(ref) {
+		candidates = []string{ref}
+	}
+	for _, p := range candidates {
+		p = filepath.Clean(p)
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		if t := m.templateByPath(p); t != nil {
+			return t, nil
+		}
+		t, err := m.loadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("加载模板 %s 失败: %v", ref, err)
+		}
+		if t.Workflow {
+			return nil, fmt.Errorf("不支持在工作流中引用工作流: %s", ref)
+		}
+		return t, nil
+	}
+
+	id := strings.TrimSuffix(filepath.Base(ref), filepath.Ext(ref))
+	if t, err := m.GetByID(id); err == nil && !t.Workflow {
+		return t, nil
+	}
+	return nil, fmt.Errorf("找不到模板: %s", ref)
+}
+
+// templateByPath 按文件路径在缓存中查找模板（包含完整内容）
+func (m *Manager) templateByPath(path string) *models.POCTemplate {
+	m.waitLoaded()
+	m.mu.RLock()
+	var id string
+	for _, t := range m.cache {
+		if filepath.Clean(t.FilePath) == path && !t.Workflow {
+			id = t.ID
+			break
+		}
+	}
+	m.mu.RUnlock()
+	if id == "" {
+		return nil
+	}
+	t, err := m.GetByID(id)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// templatesByTags 返回包含任一标签的模板（不含工作流，只有元数据，执行时再读取内容）
+func (m *Manager) templatesByTags(tags []string) []models.POCTemplate {
+	wanted := make(map[string]bool)
+	for _, tag := range tags {
+		for _, t := range strings.Split(tag, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				wanted[t] = true
+			}
+		}
+	}
+
+	m.waitLoaded()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var templates []models.POCTemplate
+	for _, t := range m.cache {
+		if t.Workflow {
+			continue
+		}
+		for _, tag := range t.Tags {
+			if wanted[strings.ToLower(tag)] {
+				templates = append(templates, t)
+				break
+			}
+		}
+	}
+	// 按 ID 排序，保证执行顺序稳定
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates
+}
@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"nuclei-poc-manager/internal/models"
+)
+
+// scanCheckpoint 扫描断点：恢复扫描需要的扫描参数，以及已完成的 (目标, 模板) 组合
+// 目标按 targets + 目标文件中的顺序编号；模板按模板 / 聚类 / 工作流 ID 记录
+type scanCheckpoint struct {
+	Options models.ScanOptions `json:"options"`
+	// DoneTargets 前 DoneTargets 个目标上的任务已全部完成
+	DoneTargets int `json:"doneTargets"`
+	// Done 之后的目标中已完成的任务：目标序号 -> 模板 / 聚类 / 工作流 ID
+	Done map[int][]string `json:"done,omitempty"`
+}
+
+// isDone 判断目标上的任务是否已完成
+func (c *scanCheckpoint) isDone(target int, unit string) bool {
+	if target < c.DoneTargets {
+		return true
+	}
+	for _, id := range c.Done[target] {
+		if id == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// markDone 记录完成的任务；units 为每个目标上的任务数，目标全部完成后推进 DoneTargets
+func (c *scanCheckpoint) markDone(target int, unit string, units int) {
+	if c.isDone(target, unit) {
+		return
+	}
+	if c.Done == nil {
+		c.Done = make(map[int][]string)
+	}
+	c.Done[target] = append(c.Done[target], unit)
+	for units > 0 && len(c.Done[c.DoneTargets]) >= units {
+		delete(c.Done, c.DoneTargets)
+		c.DoneTargets++
+	}
+}
+
+// clone 复制断点（扫描运行时用副本判断哪些任务可以跳过）
+func (c *scanCheckpoint) clone() *scanCheckpoint {
+	cp := *c
+	cp.Done = make(map[int][]string, len(c.Done))
+	for k, v := range c.Done {
+		cp.Done[k] = append([]string(nil), v...)
+	}
+	return &cp
+}
@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+func TestScanCheckpointMarkDone(t *testing.T) {
+	type mark struct {
+		target int
+		unit   string
+	}
+	tests := []struct {
+		name            string
+		units           int
+		marks           []mark
+		wantDoneTargets int
+		wantDone        map[int][]string
+	}{
+		{
+			name:            "partial target",
+			units:           3,
+			marks:           []mark{{0, "a"}, {0, "b"}},
+			wantDoneTargets: 0,
+			wantDone:        map[int][]string{0: {"a", "b"}},
+		},
+		{
+			name:            "target complete advances",
+			units:           2,
+			marks:           []mark{{0, "a"}, {0, "b"}, {1, "a"}},
+			wantDoneTargets: 1,
+			wantDone:        map[int][]string{1: {"a"}},
+		},
+		{
+			// 后面的目标先完成时，等前面的目标完成后一起推进
+			name:            "out of order",
+			units:           2,
+			marks:           []mark{{1, "a"}, {1, "b"}, {2, "a"}, {0, "b"}, {0, "a"}},
+			wantDoneTargets: 2,
+			wantDone:        map[int][]string{2: {"a"}},
+		},
+		{
+			name:            "duplicate mark ignored",
+			units:           2,
+			marks:           []mark{{0, "a"}, {0, "a"}},
+			wantDoneTargets: 0,
+			wantDone:        map[int][]string{0: {"a"}},
+		},
+		{
+			// units 为 0 时（从结果日志补记）只记录，不推进
+			name:            "no advance without units",
+			units:           0,
+			marks:           []mark{{0, "a"}, {0, "b"}},
+			wantDoneTargets: 0,
+			wantDone:        map[int][]string{0: {"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		c := &scanCheckpoint{}
+		for _, m := range tt.marks {
+			c.markDone(m.target, m.unit, tt.units)
+		}
+		if c.DoneTargets != tt.wantDoneTargets {
+			t.Errorf("%s: DoneTargets = %d, want %d", tt.name, c.DoneTargets, tt.wantDoneTargets)
+		}
+		if fmt.Sprint(c.Done) != fmt.Sprint(tt.wantDone) {
+			t.Errorf("%s: Done = %v, want %v", tt.name, c.Done, tt.wantDone)
+		}
+		for _, m := range tt.marks {
+			if !c.isDone(m.target, m.unit) {
+				t.Errorf("%s: isDone(%d, %s) = false after markDone", tt.name, m.target, m.unit)
+			}
+		}
+	}
+}
+
+func TestScanCheckpointIsDone(t *testing.T) {
+	c := &scanCheckpoint{DoneTargets: 2, Done: map[int][]string{3: {"t1"}}}
+	tests := []struct {
+		target int
+		unit   string
+		want   bool
+	}{
+		{0, "t0", true},
+		{1, "anything", true},
+		{2, "t0", false},
+		{3, "t1", true},
+		{3, "t0", false},
+		{4, "t1", false},
+	}
+	for _, tt := range tests {
+		if got := c.isDone(tt.target, tt.unit); got != tt.want {
+			t.Errorf("isDone(%d, %s) = %v, want %v", tt.target, tt.unit, got, tt.want)
+		}
+	}
+
+	// 副本与原断点互不影响
+	cp := c.clone()
+	cp.markDone(3, "t0", 0)
+	if c.isDone(3, "t0") {
+		t.Error("markDone on clone changed the original checkpoint")
+	}
+}
+
+func TestResumeSkipsCompletedPairs(t *testing.T) {
+	var mu sync.Mutex
+	var hits []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, strings.TrimPrefix(r.URL.Path, "/"))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var targets []string
+	for i := 0; i < 3; i++ {
+		targets = append(targets, fmt.Sprintf("%s/%d", srv.URL, i))
+	}
+	var templates []models.POCTemplate
+	var templateIDs []string
+	for i := 0; i < 2; i++ {
+		id := fmt.Sprintf("t%d", i)
+		templateIDs = append(templateIDs, id)
+		templates = append(templates, models.POCTemplate{ID: id, Content: fmt.Sprintf(`id: %s
+info: {name: %s}
+http:
+  - method: GET
+    path: ["{{BaseURL}}/%s"]
+    matchers:
+      - type: word
+        words: ["never-matches"]
+`, id, id, id)})
+	}
+
+	// 快照中：目标 0 全部完成，目标 1 上的 t0 已完成
+	// 结果日志中：快照之后目标 2 上的 t1 也已完成
+	dir := t.TempDir()
+	const scanID = "resume-test"
+	saved := savedScan{
+		Status: models.ScanStatus{
+			ID:          scanID,
+			Status:      "paused",
+			Total:       6,
+			Completed:   3,
+			Targets:     targets,
+			TargetCount: 3,
+			TemplateIDs: templateIDs,
+		},
+		Checkpoint: &scanCheckpoint{
+			Options:     models.ScanOptions{AllowPrivate: true, Timeout: 5, Concurrency: 2},
+			DoneTargets: 1,
+			Done:        map[int][]string{1: {"t0"}},
+		},
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, scanID+".json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, scanID+".results.jsonl"), []byte(`{"target":2,"unit":"t1","requests":1,"results":[]}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScanner(dir)
+	status, err := s.GetStatus(scanID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Resumable || status.Completed != 4 {
+		t.Fatalf("loaded status: resumable=%v completed=%d, want resumable with 4 completed", status.Resumable, status.Completed)
+	}
+	if err := s.ResumeScan(context.Background(), scanID, templates, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	final := waitScan(t, s, scanID)
+
+	mu.Lock()
+	sort.Strings(hits)
+	got := strings.Join(hits, ",")
+	mu.Unlock()
+	if want := "1/t1,2/t0"; got != want {
+		t.Errorf("requests after resume = %s, want %s", got, want)
+	}
+	if final.Status != "completed" || final.Completed != 6 {
+		t.Errorf("final status = %s, completed %d/%d", final.Status, final.Completed, final.Total)
+	}
+}
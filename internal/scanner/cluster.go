@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// templateCluster 请求完全相同的一组模板：请求只发送一次，响应分别交给各模板的 matcher / extractor
+type templateCluster struct {
+	ID        string // 首个模板 ID 加前缀，用于进度统计
+	Templates []models.POCTemplate
+	Requests  []HTTPRequest // 与 Templates 一一对应
+}
+
+// clusterTemplates 将请求完全相同的模板分组
+// 返回至少包含两个模板的聚类，其余模板原样返回
+func clusterTemplates(templates []models.POCTemplate) ([]templateCluster, []models.POCTemplate) {
+	groups := make(map[string]*templateCluster)
+	var order []string
+	var rest []models.POCTemplate
+	for _, t := range templates {
+		key, req, ok := clusterKey(t)
+		if !ok {
+			rest = append(rest, t)
+			continue
+		}
+		g, exists := groups[key]
+		if !exists {
+			g = &templateCluster{ID: "cluster:" + t.ID}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.Templates = append(g.Templates, t)
+		g.Requests = append(g.Requests, req)
+	}
+
+	var clusters []templateCluster
+	for _, key := range order {
+		g := groups[key]
+		if len(g.Templates) == 1 {
+			rest = append(rest, g.Templates[0])
+			continue
+		}
+		clusters = append(clusters, *g)
+	}
+	return clusters, rest
+}
+
+// clusterKey 计算模板请求的签名，只有结果与模板无关的请求才能聚类：
+// 单个 HTTP 请求、单个 path / raw，没有 payloads、variables、带外交互、时间盲注和 req-condition，
+// 且请求中只引用目标变量（{{BaseURL}} 等），不含随机值
+func clusterKey(template models.POCTemplate) (string, HTTPRequest, bool) {
+	content := template.Content
+	if content == "" && template.FilePath != "" {
+		data, err := os.ReadFile(template.FilePath)
+		if err != nil {
+			return "", HTTPRequest{}, false
+		}
+		content = string(data)
+	}
+	if content == "" || countProtocolRequests(content) > 0 || len(parseTemplateVariables(content)) > 0 {
+		return "", HTTPRequest{}, false
+	}
+	requests, err := parseHTTPRequestsYAML(content)
+	if err != nil || len(requests) != 1 {
+		return "", HTTPRequest{}, false
+	}
+	req := requests[0]
+	if len(requestInputs(req)) != 1 || len(req.Payloads) > 0 || req.ReqCondition || req.TimeDelay != nil ||
+		matchersUseOAST(req.Matchers) {
+		return "", HTTPRequest{}, false
+	}
+
+	input := requestInputs(req)[0]
+	headerKeys := make([]string, 0, len(req.Headers))
+	for k := range req.Headers {
+		headerKeys = append(headerKeys, k)
+	}
+	sort.Strings(headerKeys)
+
+	// 方法为空时按 GET 发送（见 doHTTPRequest），与显式写 GET 的模板归为一类
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	parts := []string{method, input, req.Body}
+	for _, k := range headerKeys {
+		parts = append(parts, k+": "+req.Headers[k])
+	}
+	for _, p := range parts {
+		if !onlyTargetVariables(p) {
+			return "", HTTPRequest{}, false
+		}
+	}
+	// 影响发送和读取方式的选项也必须一致
+	keepAlive := "scan"
+	if req.KeepAlive != nil {
+		keepAlive = fmt.Sprint(*req.KeepAlive)
+	}
+	parts = append(parts, fmt.Sprintf("unsafe=%t redirects=%t host-redirects=%t max-redirects=%d max-size=%d read-all=%t keep-alive=%s",
+		req.Unsafe, req.Redirects, req.HostRedirects, req.MaxRedirects, req.MaxSize, req.ReadAll, keepAlive))
+	return strings.Join(parts, "\x00"), req, true
+}
+
+// onlyTargetVariables 判断字符串中的 {{}} 是否只引用目标变量（或是原样保留的字面量）
+func onlyTargetVariables(s string) bool {
+	targetVars := (&targetInfo{}).variables()
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			return true
+		}
+		end := strings.Index(s[start+2:], "}}")
+		if end < 0 {
+			return true
+		}
+		expr := strings.TrimSpace(s[start+2 : start+2+end])
+		if _, ok := targetVars[expr]; !ok {
+			node, err := compileDSL(expr)
+			if err == nil && dslHasReferences(node) {
+				return false
+			}
+		}
+		s = s[start+2+end+2:]
+	}
+}
+
+// executeCluster 对聚类只发送一次请求，再用每个模板各自的上下文执行 extractor 和 matcher
+// 返回结果与逐个执行模板相同：命中的模板各自产生结果，未命中的模板产生错误结果（请求失败时为失败原因）
+func (s *Scanner) executeCluster(ctx context.Context, client *http.Client, job *ScanJob, info *targetInfo, cluster templateCluster) []*models.ScanResult {
+	target := info.BaseURL
+	respLimit := int64(job.Options.MaxResponseSize)
+	if respLimit <= 0 {
+		respLimit = DefaultMaxRespSize
+	}
+
+	lead := cluster.Requests[0]
+	leadCtx := newTemplateContext(client, cluster.Requests[:1], info)
+	ex, err := s.sendRequest(ctx, jobLimiter(job), leadCtx.client, info, lead, requestInputs(lead)[0], leadCtx.variables(), respLimit)
+
+	var results []*models.ScanResult
+	for i, template := range cluster.Templates {
+		req := cluster.Requests[i]
+		if err != nil {
+			results = append(results, newErrorResult(template, target, err.Error()))
+			continue
+		}
+
+		tctx := newTemplateContext(client, cluster.Requests[i:i+1], info)
+		tctx.job = job
+		tctx.record(ex.event)
+		tctx.applyExtractors(req.Extractors, tctx.matchEvent(ex.event))
+
+		matched, hit := checkMatchers(tctx.expandMatchers(req.Matchers), tctx.matchEvent(ex.event), req.MatchersCondition)
+		if !matched {
+			results = append(results, newErrorResult(template, target, msgNoMatch))
+			continue
+		}
+		result := newScanResult(template, target)
+		result.Matched = hit.info
+		result.MatcherNames = hit.names
+		result.ExtractedData = tctx.resultExtracted()
+		result.Request = ex.request
+		result.Response = ex.response
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,176 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// clusterTemplate 生成单请求 HTTP 模板，extra 为插入到请求中的额外字段（四个空格缩进）
+func clusterTemplate(id, extra string, words ...string) models.POCTemplate {
+	if len(words) == 0 {
+		words = []string{"x"}
+	}
+	return models.POCTemplate{ID: id, Content: fmt.Sprintf(`id: %s
+info: {name: %s, severity: info}
+http:
+  - path: ["{{BaseURL}}/"]
+%s    matchers:
+      - type: word
+        words: ["%s"]
+`, id, id, extra, strings.Join(words, `", "`))}
+}
+
+func TestClusterTemplates(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra string // 与基准模板不同的请求字段
+		want  bool   // 是否与基准模板合并
+	}{
+		{name: "same request", extra: "", want: true},
+		{name: "explicit GET", extra: "    method: GET\n", want: true},
+		{name: "method", extra: "    method: POST\n"},
+		{name: "body", extra: "    body: a=1\n"},
+		{name: "headers", extra: "    headers:\n      X-Test: 1\n"},
+		{name: "redirects", extra: "    redirects: true\n"},
+		{name: "max-redirects", extra: "    max-redirects: 3\n"},
+		{name: "max-size", extra: "    max-size: 100\n"},
+		{name: "unsafe", extra: "    unsafe: true\n"},
+		{name: "read-all", extra: "    read-all: true\n"},
+		{name: "random value", extra: "    headers:\n      X-Rand: '{{randstr}}'\n"},
+		{name: "payloads", extra: "    payloads:\n      p: [a, b]\n"},
+		{name: "req-condition", extra: "    req-condition: true\n"},
+	}
+	for _, tt := range tests {
+		base := clusterTemplate("base", "")
+		other := clusterTemplate("other", tt.extra)
+		clusters, rest := clusterTemplates([]models.POCTemplate{base, other})
+		merged := len(clusters) == 1 && len(rest) == 0
+		if merged != tt.want {
+			t.Errorf("%s: merged = %v, want %v (clusters %d, rest %d)", tt.name, merged, tt.want, len(clusters), len(rest))
+		}
+		if !tt.want && len(rest) != 2 {
+			t.Errorf("%s: rest = %d, want both templates run alone", tt.name, len(rest))
+		}
+	}
+}
+
+func TestClusterTemplatesExcludesVariables(t *testing.T) {
+	withVars := clusterTemplate("vars", "")
+	withVars.Content = strings.Replace(withVars.Content, "http:\n", "variables:\n  v: a\nhttp:\n", 1)
+
+	// 两个模板的请求相同，但含 variables 或 payloads 的模板都不参与聚类
+	templates := []models.POCTemplate{
+		clusterTemplate("a", ""),
+		withVars,
+		clusterTemplate("payloads", "    payloads:\n      p: [a]\n"),
+		clusterTemplate("b", ""),
+		clusterTemplate("c", "    method: POST\n"),
+		clusterTemplate("d", "    method: POST\n"),
+		withVars,
+	}
+	clusters, rest := clusterTemplates(templates)
+
+	var groups []string
+	for _, c := range clusters {
+		var ids []string
+		for _, t := range c.Templates {
+			ids = append(ids, t.ID)
+		}
+		if len(c.Requests) != len(c.Templates) {
+			t.Errorf("%s: %d requests for %d templates", c.ID, len(c.Requests), len(c.Templates))
+		}
+		groups = append(groups, c.ID+"="+strings.Join(ids, ","))
+	}
+	if got, want := strings.Join(groups, " "), "cluster:a=a,b cluster:c=c,d"; got != want {
+		t.Errorf("clusters = %s, want %s", got, want)
+	}
+	var restIDs []string
+	for _, t := range rest {
+		restIDs = append(restIDs, t.ID)
+	}
+	sort.Strings(restIDs)
+	if got, want := strings.Join(restIDs, ","), "payloads,vars,vars"; got != want {
+		t.Errorf("rest = %s, want %s", got, want)
+	}
+}
+
+func TestExecuteClusterAttribution(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		fmt.Fprint(w, "nginx/1.2 powered")
+	}))
+	defer srv.Close()
+
+	templates := []models.POCTemplate{
+		clusterTemplate("nginx", "", "nginx"),
+		clusterTemplate("apache", "", "apache"),
+		clusterTemplate("version", "    extractors:\n      - type: regex\n        name: ver\n        regex: ['nginx/[0-9.]+']\n", "powered"),
+		// 同名 extractor 不能串到其他模板的结果中
+		clusterTemplate("named", "    extractors:\n      - type: regex\n        name: ver\n        regex: ['missing']\n", "nginx"),
+	}
+	clusters, rest := clusterTemplates(templates)
+	if len(clusters) != 1 || len(rest) != 0 {
+		t.Fatalf("clusters = %d, rest = %d, want a single cluster", len(clusters), len(rest))
+	}
+
+	info, err := parseTarget(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScanner("")
+	job := &ScanJob{Options: models.ScanOptions{Timeout: 2}}
+	results := s.executeCluster(context.Background(), srv.Client(), job, info, clusters[0])
+
+	if hits != 1 {
+		t.Errorf("requests sent = %d, want 1", hits)
+	}
+	if len(results) != len(templates) {
+		t.Fatalf("results = %d, want %d", len(results), len(templates))
+	}
+	tests := []struct {
+		id      string
+		matched bool
+		ver     string // ver extractor 的值
+	}{
+		{id: "nginx", matched: true},
+		{id: "apache", matched: false},
+		{id: "version", matched: true, ver: "nginx/1.2"},
+		{id: "named", matched: true},
+	}
+	for i, tt := range tests {
+		r := results[i]
+		if r.TemplateID != tt.id {
+			t.Errorf("result %d: template = %s, want %s", i, r.TemplateID, tt.id)
+			continue
+		}
+		if matched := r.Matched != ""; matched != tt.matched {
+			t.Errorf("%s: matched = %q, error = %q", tt.id, r.Matched, r.Error)
+		}
+		if !tt.matched && r.Error != msgNoMatch {
+			t.Errorf("%s: error = %q, want %q", tt.id, r.Error, msgNoMatch)
+		}
+		if got := strings.Join(r.ExtractedData["ver"], ","); got != tt.ver {
+			t.Errorf("%s: ver = %q, want %q", tt.id, got, tt.ver)
+		}
+		if tt.matched && r.Host != info.BaseURL {
+			t.Errorf("%s: host = %q, want %q", tt.id, r.Host, info.BaseURL)
+		}
+	}
+
+	// 请求失败时每个模板都得到失败原因
+	srv.Close()
+	for _, r := range s.executeCluster(context.Background(), srv.Client(), job, info, clusters[0]) {
+		if r.Error == "" || r.Error == msgNoMatch {
+			t.Errorf("%s: error = %q, want the request failure", r.TemplateID, r.Error)
+		}
+	}
+}
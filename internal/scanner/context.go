@@ -0,0 +1,162 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// templateContext 单个模板在单个目标上的执行上下文（跨请求共享）
+// 多步请求链依赖它在步骤之间传递提取器变量、历史响应和 cookie
+type templateContext struct {
+	client     *http.Client           // 启用 cookie-reuse 时为带独立 cookie jar 的副本
+	vars       map[string]interface{} // 内置变量、variables 块及命名提取器产生的变量（含 internal）
+	history    map[string]interface{} // 带序号的历史响应：body_1、status_code_2 ...
+	payload    map[string]interface{} // 当前请求使用的 payload 组合
+	extracted  map[string][]string    // 需要展示在结果中的提取数据（不含 internal）
+	step       int                    // 已完成的请求数（用于响应序号）
+	chained    bool                   // 模板包含多个请求块（无 matcher 的步骤只做提取）
+	job        *ScanJob               // 所属扫描任务（延迟到达的带外交互按它追加结果）
+	lastErr    error                  // 最后一次请求失败的原因（没有命中时作为结果的错误信息）
+	oastIDs    []string               // 模板级 interactsh-url 的关联 ID（variables 块或非 HTTP 请求引用时生成）
+	oastChecks []oastCheck            // 等待带外交互的请求，模板的请求全部发送后统一等待
+}
+
+// newTemplateContext 创建模板执行上下文（vars 预置目标变量和模板级随机值）
+func newTemplateContext(client *http.Client, requests []HTTPRequest, target *targetInfo) *templateContext {
+	tctx := &templateContext{
+		client:    client,
+		vars:      builtinVariables(target),
+		history:   make(map[string]interface{}),
+		extracted: make(map[string][]string),
+		chained:   len(requests) > 1,
+	}
+
+	// 任一请求声明 cookie-reuse 时，整个模板共享一个 cookie jar
+	for _, r := range requests {
+		if !r.CookieReuse {
+			continue
+		}
+		if jar, err := cookiejar.New(nil); err == nil {
+			c := *client
+			c.Jar = jar
+			tctx.client = &c
+		}
+		break
+	}
+	return tctx
+}
+
+// jobID 所属扫描任务的 ID（单独执行模板时为空）
+func (c *templateContext) jobID() string {
+	if c.job == nil {
+		return ""
+	}
+	return c.job.ID
+}
+
+// record 记录一次响应，生成 body_N / status_code_N 等带序号的字段
+func (c *templateContext) record(event map[string]interface{}) {
+	c.step++
+	for k, v := range event {
+		c.history[fmt.Sprintf("%s_%d", k, c.step)] = v
+	}
+}
+
+// applyExtractors 执行提取器，结果写入变量供后续请求使用（多个值时变量取第一个）
+// 返回本次需要展示的提取数据（internal 提取器只作为变量，不返回）
+func (c *templateContext) applyExtractors(extractors []Extractor, event map[string]interface{}) map[string][]string {
+	visible := make(map[string][]string)
+	for i, ext := range extractors {
+		values := runExtractor(ext, event)
+		if len(values) == 0 {
+			continue
+		}
+		name := ext.Name
+		if name == "" {
+			name = fmt.Sprintf("extract_%d", i)
+		}
+		c.vars[name] = values[0]
+		if ext.Internal {
+			continue
+		}
+		visible[name] = values
+		c.extracted[name] = values
+	}
+	return visible
+}
+
+// variables 返回当前可用于展开的全部变量（历史响应 + 提取器变量）
+func (c *templateContext) variables() map[string]interface{} {
+	merged := make(map[string]interface{}, len(c.history)+len(c.vars))
+	for k, v := range c.history {
+		merged[k] = v
+	}
+	for k, v := range c.vars {
+		merged[k] = v
+	}
+	for k, v := range c.payload {
+		merged[k] = v
+	}
+	return merged
+}
+
+// matchEvent 合并当前响应与历史响应，供 matcher 按 part 取值（如 part: body_1）
+func (c *templateContext) matchEvent(event map[string]interface{}) map[string]interface{} {
+	merged := c.variables()
+	for k, v := range event {
+		merged[k] = v
+	}
+	return merged
+}
+
+// expandMatchers 展开 matcher 中 words / regex 引用的变量（如 {{token}}、{{body_1}}）
+// 无法展开的内容保留原样
+func (c *templateContext) expandMatchers(matchers []Matcher) []Matcher {
+	vars := c.variables()
+	expanded := make([]Matcher, len(matchers))
+	for i, m := range matchers {
+		if len(m.Words) > 0 {
+			words := make([]string, len(m.Words))
+			for j, w := range m.Words {
+				words[j] = expandOrKeep(w, vars)
+			}
+			m.Words = words
+		}
+		if len(m.Regex) > 0 {
+			patterns := make([]string, len(m.Regex))
+			for j, p := range m.Regex {
+				patterns[j] = expandOrKeep(p, vars)
+			}
+			m.Regex = patterns
+		}
+		expanded[i] = m
+	}
+	return expanded
+}
+
+// expandOrKeep 展开变量，失败时返回原字符串
+func expandOrKeep(s string, vars map[string]interface{}) string {
+	expanded, err := expandVariables(s, vars)
+	if err != nil {
+		return s
+	}
+	return expanded
+}
+
+// resultExtracted 返回模板执行至今累计的可展示提取数据，以及命中时使用的 payload 组合
+func (c *templateContext) resultExtracted() map[string]models.ExtractedValues {
+	if len(c.extracted) == 0 && len(c.payload) == 0 {
+		return nil
+	}
+	out := payloadData(c.payload)
+	if out == nil {
+		out = make(map[string]models.ExtractedValues, len(c.extracted))
+	}
+	for k, v := range c.extracted {
+		out[k] = append(models.ExtractedValues(nil), v...)
+	}
+	return out
+}
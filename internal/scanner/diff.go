@@ -0,0 +1,169 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// CompareScans 对比两次扫描的发现：新增、已修复、仍存在，以及无法确认是否修复的
+// 发现按模板 ID + 规范化的主机 + 命中位置匹配，与结果 ID 和发现时间无关
+func (s *Scanner) CompareScans(baseID, newID string) (*models.ScanDiff, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	baseResults, ok := s.results[baseID]
+	if !ok {
+		return nil, fmt.Errorf("扫描任务不存在: %s", baseID)
+	}
+	newResults, ok := s.results[newID]
+	if !ok {
+		return nil, fmt.Errorf("扫描任务不存在: %s", newID)
+	}
+
+	diff := &models.ScanDiff{
+		BaseScanID: baseID,
+		NewScanID:  newID,
+		New:        []models.ScanResult{},
+		Fixed:      []models.ScanResult{},
+		Persisting: []models.ScanResult{},
+		Unverified: []models.ScanResult{},
+	}
+
+	baseKeys := make(map[string]bool)
+	for _, r := range baseResults {
+		if r.Matched != "" {
+			baseKeys[findingKey(r)] = true
+		}
+	}
+
+	// 新扫描中收到响应的 模板 + 主机（命中或未命中），以及请求失败的 模板 + 主机
+	responded := make(map[string]bool)
+	for key := range s.checked[newID] {
+		responded[key] = true
+	}
+	failed := make(map[string]bool)
+	newKeys := make(map[string]bool)
+	for _, r := range newResults {
+		if r.Matched == "" {
+			if r.Error != "" {
+				failed[templateHostKey(r.TemplateID, r.Host)] = true
+			}
+			continue
+		}
+		responded[templateHostKey(r.TemplateID, r.Host)] = true
+		key := findingKey(r)
+		if newKeys[key] {
+			continue
+		}
+		newKeys[key] = true
+		if baseKeys[key] {
+			diff.Persisting = append(diff.Persisting, r)
+		} else {
+			diff.New = append(diff.New, r)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range baseResults {
+		if r.Matched == "" {
+			continue
+		}
+		key := findingKey(r)
+		if newKeys[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		// 只有新扫描确实执行了该模板并收到目标的响应，才能确认已修复
+		pair := templateHostKey(r.TemplateID, r.Host)
+		if responded[pair] && !failed[pair] {
+			diff.Fixed = append(diff.Fixed, r)
+		} else {
+			diff.Unverified = append(diff.Unverified, r)
+		}
+	}
+	return diff, nil
+}
+
+// ExportScanDiff 导出两次扫描的对比结果为 JSON（每条发现与 ExportScanResults 的格式相同）
+func (s *Scanner) ExportScanDiff(baseID, newID string) (string, error) {
+	diff, err := s.CompareScans(baseID, newID)
+	if err != nil {
+		return "", err
+	}
+
+	export := struct {
+		BaseScanID string         `json:"baseScanId"`
+		NewScanID  string         `json:"newScanId"`
+		New        []exportResult `json:"new"`
+		Fixed      []exportResult `json:"fixed"`
+		Persisting []exportResult `json:"persisting"`
+		Unverified []exportResult `json:"unverified"`
+	}{
+		BaseScanID: diff.BaseScanID,
+		NewScanID:  diff.NewScanID,
+		New:        toExportResults(diff.New),
+		Fixed:      toExportResults(diff.Fixed),
+		Persisting: toExportResults(diff.Persisting),
+		Unverified: toExportResults(diff.Unverified),
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// findingKey 发现的匹配键：模板 ID + 规范化的主机 + 命中位置
+func findingKey(r models.ScanResult) string {
+	return templateHostKey(r.TemplateID, r.Host) + "\x00" + matchedLocation(r)
+}
+
+// templateHostKey 模板 ID + 规范化的主机
+func templateHostKey(templateID, host string) string {
+	return templateID + "\x00" + normalizeHost(host)
+}
+
+// normalizeHost 规范化目标：协议和主机名小写，去掉默认端口和末尾的 /
+func normalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	u, err := url.Parse(host)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return strings.TrimSuffix(strings.ToLower(host), "/")
+	}
+	scheme := strings.ToLower(u.Scheme)
+	hostname := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		hostname = net.JoinHostPort(hostname, port)
+	} else if strings.Contains(hostname, ":") {
+		hostname = "[" + hostname + "]"
+	}
+	return scheme + "://" + hostname + strings.TrimSuffix(u.EscapedPath(), "/")
+}
+
+// matchedLocation 发现的命中位置：file 模板为命中的文件，HTTP 为请求的路径（不含查询参数），
+// 其他协议为请求记录的第一行（DNS 查询、网络地址等）
+func matchedLocation(r models.ScanResult) string {
+	if r.FilePath != "" {
+		return r.FilePath
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(r.Request), "\n")
+	line = strings.TrimSpace(line)
+	// HTTP 请求行：METHOD target HTTP/x
+	if fields := strings.Fields(line); len(fields) == 3 && strings.HasPrefix(fields[2], "HTTP/") {
+		target := fields[1]
+		if u, err := url.Parse(target); err == nil {
+			target = u.Path
+		}
+		return strings.ToUpper(fields[0]) + " " + target
+	}
+	return line
+}
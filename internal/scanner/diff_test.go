@@ -0,0 +1,171 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// waitScan 等待扫描结束并返回最终状态（在锁内复制，避免与扫描中的更新竞争）
+func waitScan(t *testing.T, s *Scanner, scanID string) models.ScanStatus {
+	t.Helper()
+	deadline := time.Now().Add(15 * time.Second)
+	for {
+		s.mu.RLock()
+		status := *s.scans[scanID].Status
+		s.mu.RUnlock()
+		if status.Status != "running" && status.Status != "queued" {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("scan %s still %s", scanID, status.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// wordTemplate 请求 {{BaseURL}}/path 并匹配响应中关键字的 HTTP 模板
+func wordTemplate(id, path, word string) models.POCTemplate {
+	return models.POCTemplate{ID: id, Name: id, Content: fmt.Sprintf(`id: %s
+info: {name: %s, severity: high}
+http:
+  - method: GET
+    path: ["{{BaseURL}}%s"]
+    matchers:
+      - type: word
+        words: ["%s"]
+`, id, id, path, word)}
+}
+
+func TestCompareScans(t *testing.T) {
+	s := NewScanner(t.TempDir())
+	s.results["a"] = []models.ScanResult{
+		{ID: "1", TemplateID: "t1", Host: "HTTP://Example.com:80/", Matched: "x", Request: "GET /a?x=1 HTTP/1.1\nHost: x"},
+		{ID: "2", TemplateID: "t2", Host: "http://example.com", Matched: "x", Request: "GET /b HTTP/1.1"},
+		{ID: "3", TemplateID: "t3", Host: "http://example.com", Matched: "x", Request: "GET /c HTTP/1.1"},
+		{ID: "4", TemplateID: "t2", Host: "http://other.com", Matched: "x", Request: "GET /b HTTP/1.1"},
+		{ID: "5", TemplateID: "t5", Host: "http://example.com", Error: "timeout"},
+	}
+	s.results["b"] = []models.ScanResult{
+		// 命中位置只比较路径，查询参数不同仍是同一个发现
+		{ID: "9", TemplateID: "t1", Host: "http://example.com", Matched: "y", Request: "GET /a?x=2 HTTP/1.1"},
+		{ID: "8", TemplateID: "t4", Host: "http://example.com", Matched: "y", Request: "GET /d HTTP/1.1"},
+		{ID: "7", TemplateID: "t2", Host: "http://other.com", Error: "timeout"},
+	}
+	// t2 在 example.com 上收到响应但未命中；t3 在新扫描中没有任何记录
+	s.markCheckedLocked("b", checkedTemplate{TemplateID: "t2", Host: "http://example.com/"})
+
+	d, err := s.CompareScans("a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(results []models.ScanResult) string {
+		var out string
+		for _, r := range results {
+			out += r.ID
+		}
+		return out
+	}
+	tests := []struct {
+		name string
+		got  []models.ScanResult
+		want string
+	}{
+		{"new", d.New, "8"},
+		{"fixed", d.Fixed, "2"},
+		{"persisting", d.Persisting, "9"},
+		{"unverified", d.Unverified, "34"},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.got); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := s.CompareScans("a", "missing"); err == nil {
+		t.Error("CompareScans with a missing scan should fail")
+	}
+}
+
+func TestCompareScansConfirmsOnlyExistingFindings(t *testing.T) {
+	var patched atomic.Bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if patched.Load() {
+			fmt.Fprint(w, "patched")
+			return
+		}
+		fmt.Fprint(w, "vulnerable")
+	})
+	up := httptest.NewServer(handler)
+	defer up.Close()
+	down := httptest.NewServer(handler)
+	defer down.Close()
+
+	dir := t.TempDir()
+	s := NewScanner(dir)
+	templates := []models.POCTemplate{
+		wordTemplate("vuln", "/vuln", "vulnerable"),
+		wordTemplate("other", "/other", "never-matches"),
+	}
+	opts := models.ScanOptions{AllowPrivate: true, Timeout: 2, Concurrency: 2}
+	targets := []string{up.URL, down.URL}
+
+	baseID, err := s.Start(context.Background(), targets, "", templates, nil, "", opts, "base", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitScan(t, s, baseID)
+
+	// 新扫描时 up 已修复，down 无法访问
+	patched.Store(true)
+	down.Close()
+	newID, err := s.Start(context.Background(), targets, "", templates, nil, "", opts, "new", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitScan(t, s, newID)
+
+	check := func(s *Scanner) {
+		t.Helper()
+		d, err := s.CompareScans(baseID, newID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(d.New) != 0 || len(d.Persisting) != 0 {
+			t.Errorf("new = %v, persisting = %v, want none", d.New, d.Persisting)
+		}
+		if len(d.Fixed) != 1 || normalizeHost(d.Fixed[0].Host) != normalizeHost(up.URL) {
+			t.Errorf("fixed = %+v, want the finding on %s", d.Fixed, up.URL)
+		}
+		if len(d.Unverified) != 1 || normalizeHost(d.Unverified[0].Host) != normalizeHost(down.URL) {
+			t.Errorf("unverified = %+v, want the finding on %s", d.Unverified, down.URL)
+		}
+	}
+	check(s)
+	// 重新加载后仍能确认
+	check(NewScanner(dir))
+
+	// 只记录基准扫描中已有发现的未命中，其他模板的未命中不保存
+	entries, err := readResultLog(s.resultLogPath(newID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var checked []checkedTemplate
+	for _, e := range entries {
+		checked = append(checked, e.Checked...)
+		for _, r := range e.Results {
+			if isNoMatch(&r) {
+				t.Errorf("no-match row persisted: %+v", r)
+			}
+		}
+	}
+	if len(checked) != 1 || checked[0].TemplateID != "vuln" {
+		t.Errorf("checked = %+v, want only vuln on %s", checked, up.URL)
+	}
+}
@@ -0,0 +1,461 @@
+package scanner
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDNSRetries DNS 查询默认尝试次数
+const DefaultDNSRetries = 3
+
+// dnsTypeCAA dnsmessage 未内置 CAA 类型
+const dnsTypeCAA = dnsmessage.Type(257)
+
+// errResolverProbe 探测系统解析服务器时阻止实际连接
+var errResolverProbe = errors.New("resolver probe")
+
+// dnsTypes 支持的查询类型
+var dnsTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"TXT":   dnsmessage.TypeTXT,
+	"MX":    dnsmessage.TypeMX,
+	"NS":    dnsmessage.TypeNS,
+	"SOA":   dnsmessage.TypeSOA,
+	"PTR":   dnsmessage.TypePTR,
+	"CAA":   dnsTypeCAA,
+}
+
+// dnsClasses 支持的查询类别
+var dnsClasses = map[string]dnsmessage.Class{
+	"INET":   dnsmessage.ClassINET,
+	"CSNET":  dnsmessage.ClassCSNET,
+	"CHAOS":  dnsmessage.ClassCHAOS,
+	"HESIOD": dnsmessage.ClassHESIOD,
+	"ANY":    dnsmessage.ClassANY,
+}
+
+// dnsRcodes 响应码名称（与 dig 输出一致）
+var dnsRcodes = map[dnsmessage.RCode]string{
+	dnsmessage.RCodeSuccess:        "NOERROR",
+	dnsmessage.RCodeFormatError:    "FORMERR",
+	dnsmessage.RCodeServerFailure:  "SERVFAIL",
+	dnsmessage.RCodeNameError:      "NXDOMAIN",
+	dnsmessage.RCodeNotImplemented: "NOTIMP",
+	dnsmessage.RCodeRefused:        "REFUSED",
+}
+
+// DNSRequest DNS 请求配置
+type DNSRequest struct {
+	Name              string   // 查询名称（支持变量，如 {{FQDN}}）
+	Type              string   // A, AAAA, CNAME, TXT, MX, NS, SOA, PTR, CAA
+	Class             string   // inet（默认）, csnet, chaos, hesiod, any
+	Recursion         bool     // 是否请求递归解析（默认 true）
+	Retries           int      // 尝试次数（多个解析服务器轮流使用）
+	Resolvers         []string // 模板指定的解析服务器（优先于扫描选项）
+	Matchers          []Matcher
+	MatchersCondition string
+	Extractors        []Extractor
+}
+
+// nucleiDNSReq Nuclei DNS 请求结构
+type nucleiDNSReq struct {
+	Name              string            `yaml:"name"`
+	Type              string            `yaml:"type"`
+	Class             string            `yaml:"class"`
+	Recursion         *bool             `yaml:"recursion"`
+	Retries           int               `yaml:"retries"`
+	Resolvers         []string          `yaml:"resolvers"`
+	MatchersCondition string            `yaml:"matchers-condition"`
+	Matchers          []nucleiMatcher   `yaml:"matchers"`
+	Extractors        []nucleiExtractor `yaml:"extractors"`
+}
+
+// parseDNSRequests 解析模板中的 dns 块（matcher / extractor 默认 part 为 raw）
+func parseDNSRequests(content string) ([]DNSRequest, error) {
+	var nt nucleiYAML
+	if err := yaml.Unmarshal([]byte(content), &nt); err != nil {
+		return nil, fmt.Errorf("YAML 解析失败: %w", err)
+	}
+
+	var requests []DNSRequest
+	for _, nd := range nt.DNS {
+		req := DNSRequest{
+			Name:              nd.Name,
+			Type:              strings.ToUpper(nd.Type),
+			Class:             strings.ToUpper(nd.Class),
+			Recursion:         nd.Recursion == nil || *nd.Recursion,
+			Retries:           nd.Retries,
+			Resolvers:         nd.Resolvers,
+			MatchersCondition: nd.MatchersCondition,
+			Matchers:          convertMatchers(nd.Matchers),
+			Extractors:        convertExtractors(nd.Extractors),
+		}
+		if req.Type == "" {
+			req.Type = "A"
+		}
+		if req.Class == "" {
+			req.Class = "INET"
+		}
+		for i := range req.Matchers {
+			if req.Matchers[i].Part == "" {
+				req.Matchers[i].Part = "raw"
+			}
+		}
+		for i := range req.Extractors {
+			if req.Extractors[i].Part == "" {
+				req.Extractors[i].Part = "raw"
+			}
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// executeDNSTemplate 依次执行模板中的 DNS 请求
+func (s *Scanner) executeDNSTemplate(ctx context.Context, job *ScanJob, info *targetInfo, template models.POCTemplate, requests []DNSRequest) []*models.ScanResult {
+	host := info.Host
+	tctx, err := s.newProtocolContext(job, info, template, len(requests))
+	if err != nil {
+		return []*models.ScanResult{newErrorResult(template, host, err.Error())}
+	}
+	tctx.vars["FQDN"] = info.Host
+
+	var results []*models.ScanResult
+	var lastErr error
+	for _, req := range requests {
+		if ctx.Err() != nil {
+			break
+		}
+		name, err := expandVariables(req.Name, tctx.variables())
+		if err != nil {
+			results = append(results, newErrorResult(template, host, err.Error()))
+			return results
+		}
+
+		resolvers := req.Resolvers
+		if len(resolvers) == 0 {
+			resolvers = job.Options.Resolvers
+		}
+		ex, err := queryDNS(ctx, jobLimiter(job), req, name, resolvers, jobTimeout(job))
+		if err != nil {
+			// 单个查询失败不影响后续请求
+			lastErr = err
+			continue
+		}
+		if r := s.matchProtocolEvent(tctx, template, host, req.Matchers, req.MatchersCondition, req.Extractors, ex.event, ex.request, ex.response); r != nil {
+			results = append(results, r)
+		}
+	}
+	results = append(results, s.resolveOASTChecks(ctx, tctx)...)
+	return finishResults(results, template, host, lastErr)
+}
+
+// queryDNS 发送 DNS 查询（UDP，响应被截断时改用 TCP），按尝试次数轮流使用解析服务器
+// 每次尝试前按扫描的速率限制等待
+func queryDNS(ctx context.Context, limiter *rateLimiter, req DNSRequest, name string, resolvers []string, timeout time.Duration) (*httpExchange, error) {
+	qtype, ok := dnsTypes[req.Type]
+	if !ok {
+		return nil, fmt.Errorf("不支持的 DNS 查询类型: %s", req.Type)
+	}
+	qclass, ok := dnsClasses[req.Class]
+	if !ok {
+		return nil, fmt.Errorf("不支持的 DNS 查询类别: %s", req.Class)
+	}
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".")
+	if qtype == dnsmessage.TypePTR {
+		name = reverseAddrName(name)
+	}
+	qname, err := dnsmessage.NewName(name + ".")
+	if err != nil {
+		return nil, fmt.Errorf("无效的查询名称 %s: %v", name, err)
+	}
+
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: uint16(rand.Intn(1 << 16)), RecursionDesired: req.Recursion},
+		Questions: []dnsmessage.Question{{Name: qname, Type: qtype, Class: qclass}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("构造 DNS 查询失败: %v", err)
+	}
+
+	if len(resolvers) == 0 {
+		if resolvers, err = systemResolvers(ctx); err != nil {
+			return nil, err
+		}
+	}
+	attempts := req.Retries
+	if attempts <= 0 {
+		attempts = DefaultDNSRetries
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if err := limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		resolver := withDefaultPort(resolvers[i%len(resolvers)], "53")
+		start := time.Now()
+		resp, err := exchangeDNS(ctx, resolver, packet, timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var msg dnsmessage.Message
+		if err := msg.Unpack(resp); err != nil {
+			lastErr = fmt.Errorf("解析 DNS 响应失败: %v", err)
+			continue
+		}
+		if msg.Header.ID != query.Header.ID {
+			lastErr = fmt.Errorf("DNS 响应 ID 不匹配")
+			continue
+		}
+		event := dnsEvent(&msg, resolver)
+		event["duration"] = time.Since(start).Seconds()
+		return &httpExchange{
+			event:    event,
+			request:  formatDNSQuestion(query.Questions[0]) + "\n;; SERVER: " + resolver,
+			response: event["raw"].(string),
+		}, nil
+	}
+	return nil, fmt.Errorf("DNS 查询失败: %v", lastErr)
+}
+
+// exchangeDNS 与单个解析服务器交换一次 DNS 报文
+func exchangeDNS(ctx context.Context, server string, packet []byte, timeout time.Duration) ([]byte, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(packet); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	resp := buf[:n]
+
+	// TC 位：响应被截断，改用 TCP 重新查询
+	var p dnsmessage.Parser
+	if h, err := p.Start(resp); err == nil && h.Truncated {
+		return exchangeDNSTCP(ctx, server, packet, timeout)
+	}
+	return resp, nil
+}
+
+// exchangeDNSTCP 通过 TCP 交换 DNS 报文（2 字节长度前缀）
+func exchangeDNSTCP(ctx context.Context, server string, packet []byte, timeout time.Duration) ([]byte, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	out := make([]byte, 2+len(packet))
+	binary.BigEndian.PutUint16(out, uint16(len(packet)))
+	copy(out[2:], packet)
+	if _, err := conn.Write(out); err != nil {
+		return nil, err
+	}
+	var size uint16
+	if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, size)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// dnsEvent 将 DNS 响应转换为按 part 取值的数据表
+// raw 为 dig 风格的完整输出，answer / ns / extra 分别为各记录段
+func dnsEvent(msg *dnsmessage.Message, resolver string) map[string]interface{} {
+	section := func(rs []dnsmessage.Resource) string {
+		lines := make([]string, 0, len(rs))
+		for _, r := range rs {
+			lines = append(lines, formatDNSResource(r))
+		}
+		return strings.Join(lines, "\n")
+	}
+	question := ""
+	if len(msg.Questions) > 0 {
+		question = formatDNSQuestion(msg.Questions[0])
+	}
+	rcode, ok := dnsRcodes[msg.Header.RCode]
+	if !ok {
+		rcode = fmt.Sprintf("RCODE%d", msg.Header.RCode)
+	}
+	answer, ns, extra := section(msg.Answers), section(msg.Authorities), section(msg.Additionals)
+
+	var raw strings.Builder
+	fmt.Fprintf(&raw, ";; ->>HEADER<<- opcode: QUERY, status: %s, id: %d\n", rcode, msg.Header.ID)
+	for _, sec := range []struct{ title, body string }{
+		{"QUESTION", question}, {"ANSWER", answer}, {"AUTHORITY", ns}, {"ADDITIONAL", extra},
+	} {
+		if sec.body != "" {
+			fmt.Fprintf(&raw, "\n;; %s SECTION:\n%s\n", sec.title, sec.body)
+		}
+	}
+	fmt.Fprintf(&raw, "\n;; SERVER: %s\n", resolver)
+
+	return map[string]interface{}{
+		"raw":      raw.String(),
+		"question": question,
+		"answer":   answer,
+		"ns":       ns,
+		"extra":    extra,
+		"rcode":    int(msg.Header.RCode),
+		"resolver": resolver,
+	}
+}
+
+// formatDNSQuestion 格式化查询段（dig 风格）
+func formatDNSQuestion(q dnsmessage.Question) string {
+	return fmt.Sprintf(";%s\t%s\t%s", q.Name.String(), dnsClassName(q.Class), dnsTypeName(q.Type))
+}
+
+// formatDNSResource 格式化单条资源记录（dig 风格）
+func formatDNSResource(r dnsmessage.Resource) string {
+	var value string
+	switch b := r.Body.(type) {
+	case *dnsmessage.AResource:
+		value = net.IP(b.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		value = net.IP(b.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		value = b.CNAME.String()
+	case *dnsmessage.NSResource:
+		value = b.NS.String()
+	case *dnsmessage.PTRResource:
+		value = b.PTR.String()
+	case *dnsmessage.MXResource:
+		value = fmt.Sprintf("%d %s", b.Pref, b.MX.String())
+	case *dnsmessage.TXTResource:
+		quoted := make([]string, len(b.TXT))
+		for i, t := range b.TXT {
+			quoted[i] = fmt.Sprintf("%q", t)
+		}
+		value = strings.Join(quoted, " ")
+	case *dnsmessage.SOAResource:
+		value = fmt.Sprintf("%s %s %d %d %d %d %d", b.NS.String(), b.MBox.String(), b.Serial, b.Refresh, b.Retry, b.Expire, b.MinTTL)
+	case *dnsmessage.UnknownResource:
+		if r.Header.Type == dnsTypeCAA {
+			value = formatCAA(b.Data)
+		} else {
+			value = hex.EncodeToString(b.Data)
+		}
+	default:
+		value = fmt.Sprint(r.Body)
+	}
+	return fmt.Sprintf("%s\t%d\t%s\t%s\t%s", r.Header.Name.String(), r.Header.TTL, dnsClassName(r.Header.Class), dnsTypeName(r.Header.Type), value)
+}
+
+// formatCAA 解析 CAA 记录：flags tag "value"
+func formatCAA(data []byte) string {
+	if len(data) < 2 || len(data) < 2+int(data[1]) {
+		return hex.EncodeToString(data)
+	}
+	tagLen := int(data[1])
+	return fmt.Sprintf("%d %s %q", data[0], data[2:2+tagLen], data[2+tagLen:])
+}
+
+// dnsTypeName 查询类型名称
+func dnsTypeName(t dnsmessage.Type) string {
+	for name, v := range dnsTypes {
+		if v == t {
+			return name
+		}
+	}
+	return strings.TrimPrefix(t.String(), "Type")
+}
+
+// dnsClassName 查询类别名称
+func dnsClassName(c dnsmessage.Class) string {
+	if c == dnsmessage.ClassINET {
+		return "IN"
+	}
+	return strings.TrimPrefix(c.String(), "Class")
+}
+
+// reverseAddrName PTR 查询时将 IP 转换为 in-addr.arpa / ip6.arpa 名称（非 IP 原样返回）
+func reverseAddrName(name string) string {
+	ip := net.ParseIP(name)
+	if ip == nil {
+		return name
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", v4[3], v4[2], v4[1], v4[0])
+	}
+	const hexDigits = "0123456789abcdef"
+	var sb strings.Builder
+	for i := len(ip) - 1; i >= 0; i-- {
+		sb.WriteByte(hexDigits[ip[i]&0x0f])
+		sb.WriteByte('.')
+		sb.WriteByte(hexDigits[ip[i]>>4])
+		sb.WriteByte('.')
+	}
+	sb.WriteString("ip6.arpa")
+	return sb.String()
+}
+
+// systemResolvers 操作系统配置的解析服务器（Linux / macOS 为 /etc/resolv.conf，Windows 为网卡的 DNS 设置）
+// 由 Go 内置解析器读取系统配置，拦截它要连接的地址，不会发出任何查询
+// 不使用公共解析服务器兜底，避免把目标信息发往外部（隔离网络中也无法访问）
+func systemResolvers(ctx context.Context) ([]string, error) {
+	var mu sync.Mutex
+	var servers []string
+	r := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, s := range servers {
+				if s == address {
+					return nil, errResolverProbe
+				}
+			}
+			servers = append(servers, address)
+			return nil, errResolverProbe
+		},
+	}
+	r.LookupTXT(ctx, "resolver-probe.invalid.")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("未找到系统配置的 DNS 解析服务器，请在扫描选项或模板中指定 resolvers")
+	}
+	return servers, nil
+}
+
+// withDefaultPort 未指定端口时补充默认端口（兼容 IPv6 地址）
+func withDefaultPort(addr, port string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	return net.JoinHostPort(strings.Trim(addr, "[]"), port)
+}
@@ -0,0 +1,640 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// —— Nuclei DSL 表达式求值 ——
+//
+// 支持的语法：
+//   字面量      'str' "str" 123 1.5 true false
+//   变量        body、status_code、content_length、duration 以及提取器变量
+//   函数调用    contains(body, "x")、md5(to_lower(body))
+//   运算符      || && ! == != =~ !~ < <= > >= + - * / % ?:
+//
+// 求值结果为 string / float64 / bool，比较时数字与数字按数值比较，其余按字符串比较
+
+// dslNode 表达式语法树节点
+type dslNode interface {
+	eval(env map[string]interface{}) (interface{}, error)
+}
+
+// errDSLUndefined 表达式引用了未定义的变量
+var errDSLUndefined = errors.New("未定义的变量")
+
+// dslCache 缓存已解析的表达式，避免每个响应重复解析
+var dslCache = sync.Map{}
+
+// evalDSL 在给定变量环境中对表达式求值
+func evalDSL(expr string, env map[string]interface{}) (interface{}, error) {
+	node, err := compileDSL(expr)
+	if err != nil {
+		return nil, err
+	}
+	return node.eval(env)
+}
+
+// evalDSLBool 求值并要求结果为布尔值（用于 dsl matcher）
+func evalDSLBool(expr string, env map[string]interface{}) (bool, error) {
+	v, err := evalDSL(expr, env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("表达式结果不是布尔值: %s", expr)
+	}
+	return b, nil
+}
+
+// compileDSL 解析表达式（带缓存）
+func compileDSL(expr string) (dslNode, error) {
+	if cached, ok := dslCache.Load(expr); ok {
+		return cached.(dslNode), nil
+	}
+	tokens, err := lexDSL(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &dslParser{tokens: tokens}
+	node, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("表达式存在多余内容: %q", p.peek().text)
+	}
+	dslCache.Store(expr, node)
+	return node, nil
+}
+
+// —— 词法分析 ——
+
+type dslTokenKind int
+
+const (
+	tokEOF dslTokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type dslToken struct {
+	kind dslTokenKind
+	text string
+	num  float64
+}
+
+// dslOperators 按长度优先排列，保证先匹配多字符运算符
+var dslOperators = []string{"||", "&&", "==", "!=", "=~", "!~", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "?", ":"}
+
+func lexDSL(expr string) ([]dslToken, error) {
+	var tokens []dslToken
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, dslToken{kind: tokLParen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, dslToken{kind: tokRParen, text: ")"})
+			i++
+		case c == ',':
+			tokens = append(tokens, dslToken{kind: tokComma, text: ","})
+			i++
+		case c == '"' || c == '\'':
+			str, n, err := lexDSLString(expr[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, dslToken{kind: tokString, text: str})
+			i += n
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(expr) && (expr[j] >= '0' && expr[j] <= '9' || expr[j] == '.') {
+				j++
+			}
+			num, err := strconv.ParseFloat(expr[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("无效的数字: %s", expr[i:j])
+			}
+			tokens = append(tokens, dslToken{kind: tokNumber, text: expr[i:j], num: num})
+			i = j
+		case isDSLIdentStart(c):
+			j := i
+			for j < len(expr) && isDSLIdentPart(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, dslToken{kind: tokIdent, text: expr[i:j]})
+			i = j
+		default:
+			matched := false
+			for _, op := range dslOperators {
+				if strings.HasPrefix(expr[i:], op) {
+					tokens = append(tokens, dslToken{kind: tokOp, text: op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("无法识别的字符 %q (位置 %d)", c, i)
+			}
+		}
+	}
+	tokens = append(tokens, dslToken{kind: tokEOF})
+	return tokens, nil
+}
+
+// lexDSLString 解析带转义的字符串字面量，返回内容和消耗的字节数
+func lexDSLString(s string) (string, int, error) {
+	quote := s[0]
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '\\', '\'', '"':
+				sb.WriteByte(s[i])
+			default:
+				// 保留未知转义（常见于正则表达式）
+				sb.WriteByte('\\')
+				sb.WriteByte(s[i])
+			}
+			continue
+		}
+		if c == quote {
+			return sb.String(), i + 1, nil
+		}
+		sb.WriteByte(c)
+	}
+	return "", 0, fmt.Errorf("字符串未闭合: %s", s)
+}
+
+func isDSLIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDSLIdentPart(c byte) bool {
+	return isDSLIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// —— 语法分析（递归下降，优先级从低到高） ——
+
+type dslParser struct {
+	tokens []dslToken
+	pos    int
+}
+
+func (p *dslParser) peek() dslToken {
+	return p.tokens[p.pos]
+}
+
+func (p *dslParser) next() dslToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *dslParser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokOp {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *dslParser) parseTernary() (dslNode, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp("?") {
+		return cond, nil
+	}
+	p.next()
+	yes, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp(":") {
+		return nil, fmt.Errorf("三元表达式缺少 ':'")
+	}
+	p.next()
+	no, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return &dslTernary{cond: cond, yes: yes, no: no}, nil
+}
+
+// dslPrecedence 二元运算符优先级分层
+var dslPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "=~", "!~"},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *dslParser) parseBinary(level int) (dslNode, error) {
+	if level >= len(dslPrecedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(dslPrecedence[level]...) {
+		op := p.next().text
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &dslBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *dslParser) parseUnary() (dslNode, error) {
+	if p.isOp("!", "-") {
+		op := p.next().text
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &dslUnary{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *dslParser) parsePrimary() (dslNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &dslLiteral{value: t.num}, nil
+	case tokString:
+		return &dslLiteral{value: t.text}, nil
+	case tokLParen:
+		node, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("缺少 ')'")
+		}
+		return node, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &dslLiteral{value: true}, nil
+		case "false":
+			return &dslLiteral{value: false}, nil
+		case "nil", "null":
+			return &dslLiteral{value: nil}, nil
+		}
+		if p.peek().kind != tokLParen {
+			return &dslIdent{name: t.text}, nil
+		}
+		p.next()
+		call := &dslCall{name: t.text}
+		if p.peek().kind == tokRParen {
+			p.next()
+			return call, nil
+		}
+		for {
+			arg, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			sep := p.next()
+			if sep.kind == tokRParen {
+				return call, nil
+			}
+			if sep.kind != tokComma {
+				return nil, fmt.Errorf("函数 %s 的参数列表格式错误", t.text)
+			}
+		}
+	case tokEOF:
+		return nil, fmt.Errorf("表达式意外结束")
+	}
+	return nil, fmt.Errorf("意外的符号: %q", t.text)
+}
+
+// —— 语法树节点求值 ——
+
+type dslLiteral struct {
+	value interface{}
+}
+
+func (n *dslLiteral) eval(env map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type dslIdent struct {
+	name string
+}
+
+func (n *dslIdent) eval(env map[string]interface{}) (interface{}, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errDSLUndefined, n.name)
+	}
+	return normalizeDSLValue(v), nil
+}
+
+type dslCall struct {
+	name string
+	args []dslNode
+}
+
+func (n *dslCall) eval(env map[string]interface{}) (interface{}, error) {
+	fn, ok := dslFunctions[n.name]
+	if !ok {
+		return nil, fmt.Errorf("未知的函数: %s", n.name)
+	}
+	args := make([]interface{}, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return normalizeDSLValue(v), nil
+}
+
+type dslUnary struct {
+	op      string
+	operand dslNode
+}
+
+func (n *dslUnary) eval(env map[string]interface{}) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !dslTruthy(v), nil
+	}
+	num, ok := dslToNumber(v)
+	if !ok {
+		return nil, fmt.Errorf("无法对非数字取负: %v", v)
+	}
+	return -num, nil
+}
+
+type dslTernary struct {
+	cond, yes, no dslNode
+}
+
+func (n *dslTernary) eval(env map[string]interface{}) (interface{}, error) {
+	c, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if dslTruthy(c) {
+		return n.yes.eval(env)
+	}
+	return n.no.eval(env)
+}
+
+type dslBinary struct {
+	op          string
+	left, right dslNode
+}
+
+func (n *dslBinary) eval(env map[string]interface{}) (interface{}, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// 逻辑运算短路求值
+	switch n.op {
+	case "&&":
+		if !dslTruthy(l) {
+			return false, nil
+		}
+		r, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		return dslTruthy(r), nil
+	case "||":
+		if dslTruthy(l) {
+			return true, nil
+		}
+		r, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		return dslTruthy(r), nil
+	}
+
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return dslEqual(l, r), nil
+	case "!=":
+		return !dslEqual(l, r), nil
+	case "=~", "!~":
+		re, err := compileRegexCached(toDSLString(r))
+		if err != nil {
+			return nil, err
+		}
+		matched := re.MatchString(toDSLString(l))
+		if n.op == "!~" {
+			return !matched, nil
+		}
+		return matched, nil
+	case "<", "<=", ">", ">=":
+		return dslCompare(n.op, l, r)
+	case "+":
+		ln, lok := l.(float64)
+		rn, rok := r.(float64)
+		if lok && rok {
+			return ln + rn, nil
+		}
+		return toDSLString(l) + toDSLString(r), nil
+	case "-", "*", "/", "%":
+		ln, lok := dslToNumber(l)
+		rn, rok := dslToNumber(r)
+		if !lok || !rok {
+			return nil, fmt.Errorf("运算符 %s 需要数字操作数", n.op)
+		}
+		switch n.op {
+		case "-":
+			return ln - rn, nil
+		case "*":
+			return ln * rn, nil
+		case "/":
+			if rn == 0 {
+				return nil, fmt.Errorf("除数为 0")
+			}
+			return ln / rn, nil
+		default:
+			if rn == 0 {
+				return nil, fmt.Errorf("除数为 0")
+			}
+			return math.Mod(ln, rn), nil
+		}
+	}
+	return nil, fmt.Errorf("不支持的运算符: %s", n.op)
+}
+
+// —— 值转换 ——
+
+// normalizeDSLValue 将事件中的各种数值类型统一为 float64，[]byte 转为 string
+func normalizeDSLValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case []byte:
+		return string(x)
+	}
+	return v
+}
+
+// toDSLString 将值转换为字符串（整数不带小数点）
+func toDSLString(v interface{}) string {
+	switch x := normalizeDSLValue(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1e15 {
+			return strconv.FormatInt(int64(x), 10)
+		}
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case []string:
+		return strings.Join(x, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// dslToNumber 尝试将值转换为数字
+func dslToNumber(v interface{}) (float64, bool) {
+	switch x := normalizeDSLValue(v).(type) {
+	case float64:
+		return x, true
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// dslTruthy 判断值的真假（空字符串、0、false、nil 为假）
+func dslTruthy(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	}
+	return true
+}
+
+func dslEqual(l, r interface{}) bool {
+	if lb, ok := l.(bool); ok {
+		rb, ok := r.(bool)
+		return ok && lb == rb
+	}
+	_, lNum := l.(float64)
+	_, rNum := r.(float64)
+	if lNum || rNum {
+		ln, lok := dslToNumber(l)
+		rn, rok := dslToNumber(r)
+		if lok && rok {
+			return ln == rn
+		}
+	}
+	return toDSLString(l) == toDSLString(r)
+}
+
+func dslCompare(op string, l, r interface{}) (bool, error) {
+	ln, lok := dslToNumber(l)
+	rn, rok := dslToNumber(r)
+	if lok && rok {
+		switch op {
+		case "<":
+			return ln < rn, nil
+		case "<=":
+			return ln <= rn, nil
+		case ">":
+			return ln > rn, nil
+		default:
+			return ln >= rn, nil
+		}
+	}
+	ls, rs := toDSLString(l), toDSLString(r)
+	switch op {
+	case "<":
+		return ls < rs, nil
+	case "<=":
+		return ls <= rs, nil
+	case ">":
+		return ls > rs, nil
+	default:
+		return ls >= rs, nil
+	}
+}
@@ -0,0 +1,562 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"html"
+	"io"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dslFunc DSL 辅助函数
+type dslFunc func(args []interface{}) (interface{}, error)
+
+// dslFunctions Nuclei 常用辅助函数（编码、哈希、字符串、正则、随机值等）
+var dslFunctions map[string]dslFunc
+
+func init() {
+	dslFunctions = map[string]dslFunc{
+		// —— 编码 ——
+		"base64": strFunc(func(s string) interface{} {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		}),
+		"base64_py": strFunc(func(s string) interface{} {
+			// Python base64.encodebytes 风格：每 76 字符换行（favicon hash 常用）
+			enc := base64.StdEncoding.EncodeToString([]byte(s))
+			var sb strings.Builder
+			for len(enc) > 76 {
+				sb.WriteString(enc[:76])
+				sb.WriteByte('\n')
+				enc = enc[76:]
+			}
+			sb.WriteString(enc)
+			sb.WriteByte('\n')
+			return sb.String()
+		}),
+		"base64_decode": strFuncErr(func(s string) (interface{}, error) {
+			data, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				data, err = base64.RawStdEncoding.DecodeString(s)
+			}
+			return string(data), err
+		}),
+		"url_encode": strFunc(func(s string) interface{} { return url.QueryEscape(s) }),
+		"url_decode": strFuncErr(func(s string) (interface{}, error) { return url.QueryUnescape(s) }),
+		"hex_encode": strFunc(func(s string) interface{} { return hex.EncodeToString([]byte(s)) }),
+		"hex_decode": strFuncErr(func(s string) (interface{}, error) {
+			data, err := hex.DecodeString(s)
+			return string(data), err
+		}),
+		"html_escape":   strFunc(func(s string) interface{} { return html.EscapeString(s) }),
+		"html_unescape": strFunc(func(s string) interface{} { return html.UnescapeString(s) }),
+		"gzip": strFuncErr(func(s string) (interface{}, error) {
+			var buf bytes.Buffer
+			w := gzip.NewWriter(&buf)
+			if _, err := w.Write([]byte(s)); err != nil {
+				return nil, err
+			}
+			w.Close()
+			return buf.String(), nil
+		}),
+		"gzip_decode": strFuncErr(func(s string) (interface{}, error) {
+			r, err := gzip.NewReader(strings.NewReader(s))
+			if err != nil {
+				return nil, err
+			}
+			data, err := io.ReadAll(r)
+			return string(data), err
+		}),
+		"zlib_decode": strFuncErr(func(s string) (interface{}, error) {
+			r, err := zlib.NewReader(strings.NewReader(s))
+			if err != nil {
+				return nil, err
+			}
+			data, err := io.ReadAll(r)
+			return string(data), err
+		}),
+
+		// —— 哈希 ——
+		"md5":    hashFunc(md5.New),
+		"sha1":   hashFunc(sha1.New),
+		"sha256": hashFunc(sha256.New),
+		"sha512": hashFunc(sha512.New),
+		"mmh3": strFunc(func(s string) interface{} {
+			return strconv.FormatInt(int64(int32(murmur3(s))), 10)
+		}),
+		"hmac": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 3); err != nil {
+				return nil, err
+			}
+			var h func() hash.Hash
+			switch strings.ToLower(toDSLString(args[0])) {
+			case "md5":
+				h = md5.New
+			case "sha1":
+				h = sha1.New
+			case "sha256":
+				h = sha256.New
+			case "sha512":
+				h = sha512.New
+			default:
+				return nil, fmt.Errorf("不支持的算法: %s", toDSLString(args[0]))
+			}
+			mac := hmac.New(h, []byte(toDSLString(args[2])))
+			mac.Write([]byte(toDSLString(args[1])))
+			return hex.EncodeToString(mac.Sum(nil)), nil
+		},
+
+		// —— 字符串 ——
+		"len": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 1); err != nil {
+				return nil, err
+			}
+			if list, ok := args[0].([]string); ok {
+				return len(list), nil
+			}
+			return len(toDSLString(args[0])), nil
+		},
+		"to_lower":   strFunc(func(s string) interface{} { return strings.ToLower(s) }),
+		"to_upper":   strFunc(func(s string) interface{} { return strings.ToUpper(s) }),
+		"trim_space": strFunc(func(s string) interface{} { return strings.TrimSpace(s) }),
+		"reverse": strFunc(func(s string) interface{} {
+			r := []rune(s)
+			for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+				r[i], r[j] = r[j], r[i]
+			}
+			return string(r)
+		}),
+		"to_string": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 1); err != nil {
+				return nil, err
+			}
+			return toDSLString(args[0]), nil
+		},
+		"to_number": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 1); err != nil {
+				return nil, err
+			}
+			n, ok := dslToNumber(args[0])
+			if !ok {
+				return nil, fmt.Errorf("无法转换为数字: %v", args[0])
+			}
+			return n, nil
+		},
+		"contains":     str2Func(func(a, b string) interface{} { return strings.Contains(a, b) }),
+		"starts_with":  strAnyFunc(strings.HasPrefix),
+		"ends_with":    strAnyFunc(strings.HasSuffix),
+		"contains_any": strAnyFunc(strings.Contains),
+		"contains_all": strAllFunc(strings.Contains),
+		"line_starts_with": strAnyFunc(func(s, prefix string) bool {
+			for _, line := range strings.Split(s, "\n") {
+				if strings.HasPrefix(line, prefix) {
+					return true
+				}
+			}
+			return false
+		}),
+		"line_ends_with": strAnyFunc(func(s, suffix string) bool {
+			for _, line := range strings.Split(s, "\n") {
+				if strings.HasSuffix(strings.TrimRight(line, "\r"), suffix) {
+					return true
+				}
+			}
+			return false
+		}),
+		"trim":        str2Func(func(a, b string) interface{} { return strings.Trim(a, b) }),
+		"trim_left":   str2Func(func(a, b string) interface{} { return strings.TrimLeft(a, b) }),
+		"trim_right":  str2Func(func(a, b string) interface{} { return strings.TrimRight(a, b) }),
+		"trim_prefix": str2Func(func(a, b string) interface{} { return strings.TrimPrefix(a, b) }),
+		"trim_suffix": str2Func(func(a, b string) interface{} { return strings.TrimSuffix(a, b) }),
+		"replace": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 3); err != nil {
+				return nil, err
+			}
+			return strings.ReplaceAll(toDSLString(args[0]), toDSLString(args[1]), toDSLString(args[2])), nil
+		},
+		"replace_regex": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 3); err != nil {
+				return nil, err
+			}
+			re, err := compileRegexCached(toDSLString(args[1]))
+			if err != nil {
+				return nil, err
+			}
+			return re.ReplaceAllString(toDSLString(args[0]), toDSLString(args[2])), nil
+		},
+		"repeat": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 2); err != nil {
+				return nil, err
+			}
+			n, ok := dslToNumber(args[1])
+			if !ok || n < 0 {
+				return nil, fmt.Errorf("无效的次数: %v", args[1])
+			}
+			return strings.Repeat(toDSLString(args[0]), int(n)), nil
+		},
+		"concat": func(args []interface{}) (interface{}, error) {
+			var sb strings.Builder
+			for _, a := range args {
+				sb.WriteString(toDSLString(a))
+			}
+			return sb.String(), nil
+		},
+		"join": func(args []interface{}) (interface{}, error) {
+			if len(args) < 1 {
+				return nil, fmt.Errorf("参数不足")
+			}
+			parts := make([]string, 0, len(args)-1)
+			for _, a := range args[1:] {
+				if list, ok := a.([]string); ok {
+					parts = append(parts, list...)
+					continue
+				}
+				parts = append(parts, toDSLString(a))
+			}
+			return strings.Join(parts, toDSLString(args[0])), nil
+		},
+		"split": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 2); err != nil {
+				return nil, err
+			}
+			return strings.Split(toDSLString(args[0]), toDSLString(args[1])), nil
+		},
+		"substr": func(args []interface{}) (interface{}, error) {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("参数不足")
+			}
+			s := toDSLString(args[0])
+			start, _ := dslToNumber(args[1])
+			end := float64(len(s))
+			if len(args) > 2 {
+				end, _ = dslToNumber(args[2])
+			}
+			if start < 0 || int(start) > len(s) || end < start {
+				return nil, fmt.Errorf("下标越界")
+			}
+			if int(end) > len(s) {
+				end = float64(len(s))
+			}
+			return s[int(start):int(end)], nil
+		},
+		"index": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 2); err != nil {
+				return nil, err
+			}
+			return strings.Index(toDSLString(args[0]), toDSLString(args[1])), nil
+		},
+		"remove_bad_chars": str2Func(func(s, cutset string) interface{} {
+			return strings.Map(func(r rune) rune {
+				if strings.ContainsRune(cutset, r) {
+					return -1
+				}
+				return r
+			}, s)
+		}),
+
+		// —— 正则 ——
+		"regex": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 2); err != nil {
+				return nil, err
+			}
+			re, err := compileRegexCached(toDSLString(args[0]))
+			if err != nil {
+				return nil, err
+			}
+			return re.MatchString(toDSLString(args[1])), nil
+		},
+		"regex_all": func(args []interface{}) (interface{}, error) {
+			if err := needArgs(args, 2); err != nil {
+				return nil, err
+			}
+			re, err := compileRegexCached(toDSLString(args[0]))
+			if err != nil {
+				return nil, err
+			}
+			return re.FindAllString(toDSLString(args[1]), -1), nil
+		},
+
+		// —— 随机值 ——
+		"rand_base": func(args []interface{}) (interface{}, error) {
+			if len(args) < 1 {
+				return nil, fmt.Errorf("参数不足")
+			}
+			charset := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+			if len(args) > 1 && toDSLString(args[1]) != "" {
+				charset = toDSLString(args[1])
+			}
+			n, _ := dslToNumber(args[0])
+			return randFromCharset(int(n), charset), nil
+		},
+		"rand_char": func(args []interface{}) (interface{}, error) {
+			charset := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+			if len(args) > 0 && toDSLString(args[0]) != "" {
+				charset = toDSLString(args[0])
+			}
+			return randFromCharset(1, charset), nil
+		},
+		"rand_text_alpha":        randTextFunc("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"),
+		"rand_text_alphanumeric": randTextFunc("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"),
+		"rand_text_numeric":      randTextFunc("0123456789"),
+		"rand_int": func(args []interface{}) (interface{}, error) {
+			min, max := 0.0, 2147483647.0
+			if len(args) > 0 {
+				min, _ = dslToNumber(args[0])
+			}
+			if len(args) > 1 {
+				max, _ = dslToNumber(args[1])
+			}
+			if max <= min {
+				return min, nil
+			}
+			return min + float64(rand.Int63n(int64(max-min))), nil
+		},
+		"rand_ip": func(args []interface{}) (interface{}, error) {
+			return fmt.Sprintf("%d.%d.%d.%d", rand.Intn(223)+1, rand.Intn(256), rand.Intn(256), rand.Intn(254)+1), nil
+		},
+
+		// —— 时间 / 版本 ——
+		"unix_time": func(args []interface{}) (interface{}, error) {
+			offset := 0.0
+			if len(args) > 0 {
+				offset, _ = dslToNumber(args[0])
+			}
+			return float64(time.Now().Unix()) + offset, nil
+		},
+		"compare_versions": func(args []interface{}) (interface{}, error) {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("参数不足")
+			}
+			version := toDSLString(args[0])
+			for _, c := range args[1:] {
+				ok, err := versionSatisfies(version, toDSLString(c))
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					return false, nil
+				}
+			}
+			return true, nil
+		},
+	}
+}
+
+// —— 函数构造辅助 ——
+
+func needArgs(args []interface{}, n int) error {
+	if len(args) < n {
+		return fmt.Errorf("需要 %d 个参数，实际 %d 个", n, len(args))
+	}
+	return nil
+}
+
+func strFunc(f func(string) interface{}) dslFunc {
+	return func(args []interface{}) (interface{}, error) {
+		if err := needArgs(args, 1); err != nil {
+			return nil, err
+		}
+		return f(toDSLString(args[0])), nil
+	}
+}
+
+func strFuncErr(f func(string) (interface{}, error)) dslFunc {
+	return func(args []interface{}) (interface{}, error) {
+		if err := needArgs(args, 1); err != nil {
+			return nil, err
+		}
+		return f(toDSLString(args[0]))
+	}
+}
+
+func str2Func(f func(a, b string) interface{}) dslFunc {
+	return func(args []interface{}) (interface{}, error) {
+		if err := needArgs(args, 2); err != nil {
+			return nil, err
+		}
+		return f(toDSLString(args[0]), toDSLString(args[1])), nil
+	}
+}
+
+// strAnyFunc 第一个参数与后续任一参数满足 f 即为真
+func strAnyFunc(f func(s, sub string) bool) dslFunc {
+	return func(args []interface{}) (interface{}, error) {
+		if err := needArgs(args, 2); err != nil {
+			return nil, err
+		}
+		s := toDSLString(args[0])
+		for _, a := range args[1:] {
+			if f(s, toDSLString(a)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// strAllFunc 第一个参数与后续所有参数都满足 f 才为真
+func strAllFunc(f func(s, sub string) bool) dslFunc {
+	return func(args []interface{}) (interface{}, error) {
+		if err := needArgs(args, 2); err != nil {
+			return nil, err
+		}
+		s := toDSLString(args[0])
+		for _, a := range args[1:] {
+			if !f(s, toDSLString(a)) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
+
+func hashFunc(h func() hash.Hash) dslFunc {
+	return strFunc(func(s string) interface{} {
+		hh := h()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	})
+}
+
+func randTextFunc(charset string) dslFunc {
+	return func(args []interface{}) (interface{}, error) {
+		n := 8.0
+		if len(args) > 0 {
+			n, _ = dslToNumber(args[0])
+		}
+		return randFromCharset(int(n), charset), nil
+	}
+}
+
+func randFromCharset(n int, charset string) string {
+	if n <= 0 || charset == "" {
+		return ""
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = charset[rand.Intn(len(charset))]
+	}
+	return string(b)
+}
+
+// murmur3 MurmurHash3 x86 32 位（seed 0），与 Python mmh3.hash 一致
+func murmur3(s string) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	data := []byte(s)
+	var h uint32
+	n := len(data) / 4
+	for i := 0; i < n; i++ {
+		k := uint32(data[i*4]) | uint32(data[i*4+1])<<8 | uint32(data[i*4+2])<<16 | uint32(data[i*4+3])<<24
+		k *= c1
+		k = k<<15 | k>>17
+		k *= c2
+		h ^= k
+		h = h<<13 | h>>19
+		h = h*5 + 0xe6546b64
+	}
+	var k uint32
+	tail := data[n*4:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = k<<15 | k>>17
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// versionSatisfies 判断版本是否满足约束（如 "< 4.2.1"、">= 3.0"）
+func versionSatisfies(version, constraint string) (bool, error) {
+	constraint = strings.TrimSpace(constraint)
+	op := "=="
+	for _, candidate := range []string{"<=", ">=", "!=", "==", "<", ">", "="} {
+		if strings.HasPrefix(constraint, candidate) {
+			op = candidate
+			constraint = strings.TrimSpace(constraint[len(candidate):])
+			break
+		}
+	}
+	cmp := compareVersionStrings(version, constraint)
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "!=":
+		return cmp != 0, nil
+	}
+	return cmp == 0, nil
+}
+
+// compareVersionStrings 逐段比较版本号，数字段按数值比较
+func compareVersionStrings(a, b string) int {
+	split := func(v string) []string {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+		return strings.FieldsFunc(v, func(r rune) bool { return r == '.' || r == '-' || r == '_' })
+	}
+	pa, pb := split(a), split(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y string
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		if x == "" {
+			xerr, xn = nil, 0
+		}
+		if y == "" {
+			yerr, yn = nil, 0
+		}
+		if xerr == nil && yerr == nil {
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,191 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalDSLPrecedence(t *testing.T) {
+	env := map[string]interface{}{
+		"status_code": 200,
+		"body":        "Welcome to nginx",
+	}
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"1 + 2 * 3", float64(7)},
+		{"(1 + 2) * 3", float64(9)},
+		{"10 - 4 - 3", float64(3)},
+		{"20 / 4 / 5", float64(1)},
+		{"7 % 4 * 2", float64(6)},
+		{"-2 * 3", float64(-6)},
+		{"1 + 2 == 3", true},
+		{"2 * 3 > 5 && 1 < 2", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"!false && true", true},
+		{"!(1 == 1) || status_code == 200", true},
+		{"status_code == 200 && contains(body, 'nginx')", true},
+		{"status_code >= 500 || contains(body, 'apache')", false},
+		{"status_code == 200 ? 'ok' : 'fail'", "ok"},
+		{"status_code == 404 ? 'ok' : 1 + 1 == 2 ? 'two' : 'other'", "two"},
+		{"'a' + 'b' == 'ab'", true},
+		{"'1' + 2", "12"},
+		{"body =~ '^Welcome' && body !~ 'apache'", true},
+		{"'10' == 10", true},
+		{"'abc' < 'abd'", true},
+	}
+	for _, tt := range tests {
+		got, err := evalDSL(tt.expr, env)
+		if err != nil {
+			t.Errorf("evalDSL(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("evalDSL(%q) = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvalDSLShortCircuit(t *testing.T) {
+	// 右侧引用了未定义的变量，短路求值时不应报错
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"false && missing == 1", false},
+		{"true || missing == 1", true},
+	}
+	for _, tt := range tests {
+		got, err := evalDSLBool(tt.expr, map[string]interface{}{})
+		if err != nil {
+			t.Errorf("evalDSLBool(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("evalDSLBool(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvalDSLErrors(t *testing.T) {
+	env := map[string]interface{}{"zero": 0}
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"1 / 0", "除数为 0"},
+		{"10 % 0", "除数为 0"},
+		{"5 / zero", "除数为 0"},
+		{"5 / (2 - 2)", "除数为 0"},
+		{"'abc' * 2", "需要数字操作数"},
+		{"missing == 1", "未定义的变量"},
+		{"no_such_func('x')", "未知的函数"},
+		{"substr('abc', 5)", "下标越界"},
+		{"1 +", ""},
+		{"(1 + 2", ""},
+	}
+	for _, tt := range tests {
+		_, err := evalDSL(tt.expr, env)
+		if err == nil {
+			t.Errorf("evalDSL(%q) expected error", tt.expr)
+			continue
+		}
+		if tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("evalDSL(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEvalDSLBoolRequiresBool(t *testing.T) {
+	if _, err := evalDSLBool("1 + 1", nil); err == nil {
+		t.Error("evalDSLBool on a number should fail")
+	}
+}
+
+func TestDSLFunctions(t *testing.T) {
+	env := map[string]interface{}{
+		"body":   "Hello World",
+		"header": "Server: nginx/1.18.0\nX-Powered-By: PHP/7.4",
+	}
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		// 编码
+		{"base64('admin:admin')", "YWRtaW46YWRtaW4="},
+		{"base64_decode('YWRtaW46YWRtaW4=')", "admin:admin"},
+		{"url_encode('a b&c')", "a+b%26c"},
+		{"url_decode('a+b%26c')", "a b&c"},
+		{"hex_encode('abc')", "616263"},
+		{"hex_decode('616263')", "abc"},
+		{"html_escape('<a>')", "&lt;a&gt;"},
+		{"html_unescape('&lt;a&gt;')", "<a>"},
+		{"gzip_decode(gzip('payload'))", "payload"},
+		// 哈希
+		{"md5('abc')", "900150983cd24fb0d6963f7d28e17f72"},
+		{"sha1('abc')", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"sha256('abc')", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"hmac('sha256', 'data', 'key')", "5031fe3d989c6d1537a013fa6e739da23463fdaec3b70137d828e36ace221bd0"},
+		// 字符串
+		{"len(body)", float64(11)},
+		{"len(split('a,b,c', ','))", float64(3)},
+		{"to_lower(body)", "hello world"},
+		{"to_upper('abc')", "ABC"},
+		{"trim_space('  x  ')", "x"},
+		{"reverse('abc')", "cba"},
+		{"contains(body, 'World')", true},
+		{"contains(to_lower(body), 'WORLD')", false},
+		{"starts_with(body, 'Hell')", true},
+		{"ends_with(body, 'xyz')", false},
+		{"contains_any(body, 'foo', 'World')", true},
+		{"contains_all(body, 'Hello', 'foo')", false},
+		{"line_starts_with(header, 'X-Powered-By')", true},
+		{"replace(body, 'World', 'nuclei')", "Hello nuclei"},
+		{"replace_regex('a1b22c', '[0-9]+', '-')", "a-b-c"},
+		{"repeat('ab', 3)", "ababab"},
+		{"concat('a', 1, true)", "a1true"},
+		{"join('-', 'a', 'b')", "a-b"},
+		{"substr('abcdef', 1, 3)", "bc"},
+		{"index(body, 'World')", float64(6)},
+		{"trim_prefix(body, 'Hello ')", "World"},
+		{"to_number('42') + 1", float64(43)},
+		// 正则与版本
+		{"regex('nginx/[0-9.]+', header)", true},
+		{"regex('apache', header)", false},
+		{"compare_versions('1.18.0', '>= 1.10.0', '< 1.20')", true},
+		{"compare_versions('1.18.0', '> 1.18.0')", false},
+	}
+	for _, tt := range tests {
+		got, err := evalDSL(tt.expr, env)
+		if err != nil {
+			t.Errorf("evalDSL(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("evalDSL(%q) = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestDSLRandomFunctions(t *testing.T) {
+	tests := []struct {
+		expr  string
+		check func(s string) bool
+	}{
+		{"rand_text_numeric(6)", func(s string) bool { return len(s) == 6 && strings.Trim(s, "0123456789") == "" }},
+		{"rand_text_alpha(8)", func(s string) bool { return len(s) == 8 && strings.Trim(s, "0123456789") == s }},
+		{"rand_base(5, 'x')", func(s string) bool { return s == "xxxxx" }},
+	}
+	for _, tt := range tests {
+		v, err := evalDSL(tt.expr, nil)
+		if err != nil {
+			t.Errorf("evalDSL(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if s := toDSLString(v); !tt.check(s) {
+			t.Errorf("evalDSL(%q) = %q", tt.expr, s)
+		}
+	}
+}
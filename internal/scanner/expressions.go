@@ -0,0 +1,146 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// errUnresolvedVariable 模板引用了未定义的变量（不发送请求，错误记录到结果中）
+var errUnresolvedVariable = errors.New("存在未解析的变量")
+
+// expandVariables 展开字符串中的 {{表达式}}
+// 表达式可以是变量（{{BaseURL}}、{{username}}）或嵌套的辅助函数调用（{{base64(username)}}）
+// 语法无法解析、或不引用任何变量/函数的纯字面量（如 SSTI 载荷 {{7*7}}）原样保留
+func expandVariables(s string, vars map[string]interface{}) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start+2:], "}}")
+		if end < 0 {
+			break
+		}
+		end += start + 2
+
+		sb.WriteString(s[:start])
+		value, ok, err := evalTemplateExpr(strings.TrimSpace(s[start+2:end]), vars)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			sb.WriteString(value)
+		} else {
+			sb.WriteString(s[start : end+2])
+		}
+		s = s[end+2:]
+	}
+	sb.WriteString(s)
+	return sb.String(), nil
+}
+
+// evalTemplateExpr 对单个 {{}} 内的表达式求值，ok=false 表示应原样保留
+func evalTemplateExpr(expr string, vars map[string]interface{}) (string, bool, error) {
+	// 直接引用变量（也支持 interactsh-url 这类不符合表达式语法的名称）
+	if v, found := vars[expr]; found {
+		return toDSLString(v), true, nil
+	}
+
+	node, err := compileDSL(expr)
+	if err != nil || !dslHasReferences(node) {
+		return "", false, nil
+	}
+	v, err := node.eval(vars)
+	if err != nil {
+		if errors.Is(err, errDSLUndefined) {
+			return "", false, fmt.Errorf("%w: {{%s}} (%v)", errUnresolvedVariable, expr, err)
+		}
+		return "", false, fmt.Errorf("表达式 {{%s}} 求值失败: %v", expr, err)
+	}
+	return toDSLString(v), true, nil
+}
+
+// dslHasReferences 判断表达式是否引用了变量或函数
+func dslHasReferences(node dslNode) bool {
+	switch n := node.(type) {
+	case *dslIdent, *dslCall:
+		return true
+	case *dslUnary:
+		return dslHasReferences(n.operand)
+	case *dslBinary:
+		return dslHasReferences(n.left) || dslHasReferences(n.right)
+	case *dslTernary:
+		return dslHasReferences(n.cond) || dslHasReferences(n.yes) || dslHasReferences(n.no)
+	}
+	return false
+}
+
+// builtinVariables 目标相关变量及模板级随机值（同一模板执行期间保持不变）
+func builtinVariables(target *targetInfo) map[string]interface{} {
+	vars := target.variables()
+	now := time.Now().Unix()
+	vars["randstr"] = randStr(8)
+	vars["randstr_10"] = randStr(10)
+	vars["randint"] = rand.Intn(999999)
+	vars["unix_time"] = now
+	vars["timestamp"] = now
+	return vars
+}
+
+// parseTemplateVariables 读取模板顶层的 variables 块
+func parseTemplateVariables(content string) map[string]string {
+	var nt struct {
+		Variables map[string]string `yaml:"variables"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &nt); err != nil {
+		return nil
+	}
+	return nt.Variables
+}
+
+// resolveTemplateVariables 按依赖关系解析声明的变量（变量之间可以相互引用，与声明顺序无关）
+func resolveTemplateVariables(decl map[string]string, env map[string]interface{}) error {
+	pending := make(map[string]string, len(decl))
+	for k, v := range decl {
+		pending[k] = v
+	}
+
+	for len(pending) > 0 {
+		progress := false
+		var lastErr error
+		for name, raw := range pending {
+			value, err := expandVariables(raw, env)
+			if err != nil {
+				if errors.Is(err, errUnresolvedVariable) {
+					// 可能依赖尚未解析的其他变量，下一轮再试
+					lastErr = err
+					continue
+				}
+				return fmt.Errorf("变量 %s 解析失败: %v", name, err)
+			}
+			env[name] = value
+			delete(pending, name)
+			progress = true
+		}
+		if !progress {
+			names := make([]string, 0, len(pending))
+			for name := range pending {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("变量 %s 无法解析: %v", strings.Join(names, ", "), lastErr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExpandVariables(t *testing.T) {
+	vars := map[string]interface{}{
+		"BaseURL":        "http://example.com",
+		"username":       "admin",
+		"port":           8080,
+		"interactsh-url": "abc.oast.example",
+	}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"no variables", "no variables"},
+		{"{{BaseURL}}/login", "http://example.com/login"},
+		{"{{ BaseURL }}/a?u={{username}}", "http://example.com/a?u=admin"},
+		{"port={{port}}", "port=8080"},
+		{"http://{{interactsh-url}}", "http://abc.oast.example"},
+		{"{{base64(username)}}", "YWRtaW4="},
+		{"{{to_upper(concat(username, '-', port))}}", "ADMIN-8080"},
+		{"{{port + 1}}", "8081"},
+		// 不引用变量的字面量表达式（如 SSTI 载荷）原样保留
+		{"{{7*7}}", "{{7*7}}"},
+		{"${{'a'}}", "${{'a'}}"},
+		// 无法解析的语法原样保留
+		{"{{#each items}}", "{{#each items}}"},
+		// 未闭合的 {{ 原样保留
+		{"{{BaseURL}}/{{unclosed", "http://example.com/{{unclosed"},
+	}
+	for _, tt := range tests {
+		got, err := expandVariables(tt.in, vars)
+		if err != nil {
+			t.Errorf("expandVariables(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expandVariables(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpandVariablesUnresolved(t *testing.T) {
+	vars := map[string]interface{}{"username": "admin"}
+	tests := []struct {
+		in         string
+		unresolved bool
+	}{
+		{"{{password}}", true},
+		{"/login?u={{username}}&p={{password}}", true},
+		{"{{base64(password)}}", true},
+		{"{{username + missing}}", true},
+		{"{{md5(username) == hash ? 'a' : 'b'}}", true},
+		// 求值失败但不是未定义变量
+		{"{{no_such_func(username)}}", false},
+		{"{{to_number(username) / 0}}", false},
+	}
+	for _, tt := range tests {
+		_, err := expandVariables(tt.in, vars)
+		if err == nil {
+			t.Errorf("expandVariables(%q) expected error", tt.in)
+			continue
+		}
+		if got := errors.Is(err, errUnresolvedVariable); got != tt.unresolved {
+			t.Errorf("expandVariables(%q) error = %v, errUnresolvedVariable = %v, want %v", tt.in, err, got, tt.unresolved)
+		}
+	}
+}
+
+func TestResolveTemplateVariables(t *testing.T) {
+	env := map[string]interface{}{"Hostname": "example.com"}
+	decl := map[string]string{
+		// 引用了后声明的变量，解析顺序与声明顺序无关
+		"token":  "{{md5(user)}}",
+		"user":   "{{prefix}}admin",
+		"prefix": "x-",
+		"origin": "https://{{Hostname}}",
+	}
+	if err := resolveTemplateVariables(decl, env); err != nil {
+		t.Fatalf("resolveTemplateVariables error: %v", err)
+	}
+	want := map[string]string{
+		"prefix": "x-",
+		"user":   "x-admin",
+		"token":  "8b32e336e963681392fb0ffef072829a",
+		"origin": "https://example.com",
+	}
+	for name, v := range want {
+		if got := toDSLString(env[name]); got != v {
+			t.Errorf("%s = %q, want %q", name, got, v)
+		}
+	}
+}
+
+func TestResolveTemplateVariablesUnresolved(t *testing.T) {
+	tests := []struct {
+		name  string
+		decl  map[string]string
+		names string
+	}{
+		{"undefined", map[string]string{"a": "{{missing}}"}, "a"},
+		{"cycle", map[string]string{"a": "{{b}}", "b": "{{a}}"}, "a, b"},
+		{"partial", map[string]string{"ok": "1", "a": "{{b}}-x", "b": "{{missing}}"}, "a, b"},
+	}
+	for _, tt := range tests {
+		err := resolveTemplateVariables(tt.decl, map[string]interface{}{})
+		if err == nil {
+			t.Errorf("%s: expected error", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), "变量 "+tt.names+" 无法解析") {
+			t.Errorf("%s: error = %v, want variables %q", tt.name, err, tt.names)
+		}
+	}
+}
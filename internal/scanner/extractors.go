@@ -0,0 +1,155 @@
+package scanner
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"github.com/itchyny/gojq"
+)
+
+// normalizeKVKey 规范化 kval 名称：Content-Type、content-type、content_type 视为相同
+func normalizeKVKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(key), "-", "_"))
+}
+
+// kvalLookup 按名称读取响应头或 cookie（part 为 header / cookie 时只查对应来源）
+func kvalLookup(event map[string]interface{}, part, key string) []string {
+	var values []string
+	if part != "cookie" {
+		if headers, ok := event["headers"].(map[string]string); ok {
+			if v, ok := headers[normalizeKVKey(key)]; ok {
+				values = append(values, v)
+			}
+		}
+	}
+	if part != "header" {
+		if cookies, ok := event["cookies"].(map[string]string); ok {
+			if v, ok := cookies[key]; ok {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+var jqCache sync.Map // 查询表达式 -> *gojq.Code
+
+// compileJQ 编译 jq 查询（带缓存）
+func compileJQ(expr string) (*gojq.Code, error) {
+	if cached, ok := jqCache.Load(expr); ok {
+		return cached.(*gojq.Code), nil
+	}
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, err
+	}
+	jqCache.Store(expr, code)
+	return code, nil
+}
+
+// extractJSON 对 JSON 内容执行 jq 查询，非字符串结果序列化为 JSON
+func extractJSON(content string, queries []string) []string {
+	// 内容可能是多个连续的 JSON 值（如 WebSocket 的多条消息），逐个执行查询
+	var docs []interface{}
+	dec := json.NewDecoder(strings.NewReader(content))
+	for {
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			break
+		}
+		docs = append(docs, doc)
+	}
+
+	var values []string
+	for _, expr := range queries {
+		code, err := compileJQ(expr)
+		if err != nil {
+			continue
+		}
+		for _, doc := range docs {
+			values = append(values, runJQ(code, doc)...)
+		}
+	}
+	return values
+}
+
+// runJQ 对单个 JSON 值执行查询，非字符串结果序列化为 JSON
+func runJQ(code *gojq.Code, data interface{}) []string {
+	var values []string
+	iter := code.Run(data)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		switch x := v.(type) {
+		case error, nil:
+			continue
+		case string:
+			values = append(values, x)
+		default:
+			if b, err := json.Marshal(x); err == nil {
+				values = append(values, string(b))
+			}
+		}
+	}
+	return values
+}
+
+// extractXPath 对 HTML / XML 内容执行 XPath 查询（以 <?xml 开头时按 XML 解析）
+// attribute 不为空时提取节点属性，否则提取节点文本
+func extractXPath(content string, queries []string, attribute string) []string {
+	if strings.HasPrefix(strings.TrimSpace(content), "<?xml") {
+		return extractXMLPath(content, queries, attribute)
+	}
+
+	doc, err := htmlquery.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil
+	}
+	var values []string
+	for _, expr := range queries {
+		nodes, err := htmlquery.QueryAll(doc, expr)
+		if err != nil {
+			continue
+		}
+		for _, node := range nodes {
+			if attribute != "" {
+				values = append(values, htmlquery.SelectAttr(node, attribute))
+			} else {
+				values = append(values, strings.TrimSpace(htmlquery.InnerText(node)))
+			}
+		}
+	}
+	return values
+}
+
+// extractXMLPath 对 XML 文档执行 XPath 查询
+func extractXMLPath(content string, queries []string, attribute string) []string {
+	doc, err := xmlquery.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil
+	}
+	var values []string
+	for _, expr := range queries {
+		nodes, err := xmlquery.QueryAll(doc, expr)
+		if err != nil {
+			continue
+		}
+		for _, node := range nodes {
+			if attribute != "" {
+				values = append(values, node.SelectAttr(attribute))
+			} else {
+				values = append(values, strings.TrimSpace(node.InnerText()))
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,456 @@
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"nuclei-poc-manager/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFileMaxSize file 模板默认的单文件大小上限
+const DefaultFileMaxSize = 10 << 20
+
+// maxFileLines 单个结果最多记录的行号数
+const maxFileLines = 50
+
+// defaultFileDenylist 未指定 extensions 时默认跳过的二进制 / 媒体文件扩展名
+var defaultFileDenylist = []string{
+	".3gp", ".avi", ".bmp", ".class", ".dll", ".doc", ".docx", ".eot", ".exe", ".flv",
+	".gif", ".ico", ".iso", ".jpeg", ".jpg", ".mkv", ".mov", ".mp3", ".mp4", ".mpeg",
+	".msi", ".o", ".ogg", ".otf", ".pdf", ".png", ".ppt", ".pptx", ".psd", ".pyc",
+	".so", ".svg", ".swf", ".sys", ".tif", ".tiff", ".ttf", ".wav", ".webm", ".webp",
+	".wmv", ".woff", ".woff2", ".xls", ".xlsx",
+}
+
+// FileRequest 本地文件扫描请求配置
+type FileRequest struct {
+	Extensions        []string // 只扫描这些扩展名（为空或包含 all 时扫描全部）
+	DenyList          []string // 排除的扩展名（.xxx）或文件 / 目录名、路径片段
+	MaxSize           int64    // 单文件大小上限（字节）
+	Archive           bool     // 扫描目录中遇到的压缩包内容（目标本身是压缩包时总是解包）
+	NoRecursive       bool     // 只扫描目标目录的第一层
+	Matchers          []Matcher
+	MatchersCondition string
+	Extractors        []Extractor
+}
+
+// nucleiFileReq Nuclei file 请求结构
+type nucleiFileReq struct {
+	Extensions        []string          `yaml:"extensions"`
+	DenyList          []string          `yaml:"denylist"`
+	MaxSize           string            `yaml:"max-size"`
+	Archive           bool              `yaml:"archive"`
+	NoRecursive       bool              `yaml:"no-recursive"`
+	MatchersCondition string            `yaml:"matchers-condition"`
+	Matchers          []nucleiMatcher   `yaml:"matchers"`
+	Extractors        []nucleiExtractor `yaml:"extractors"`
+}
+
+// parseFileRequests 解析模板中的 file 块
+func parseFileRequests(content string) ([]FileRequest, error) {
+	var nt nucleiYAML
+	if err := yaml.Unmarshal([]byte(content), &nt); err != nil {
+		return nil, fmt.Errorf("YAML 解析失败: %w", err)
+	}
+
+	var requests []FileRequest
+	for _, nf := range nt.File {
+		req := FileRequest{
+			DenyList:          nf.DenyList,
+			MaxSize:           DefaultFileMaxSize,
+			Archive:           nf.Archive,
+			NoRecursive:       nf.NoRecursive,
+			MatchersCondition: nf.MatchersCondition,
+			Matchers:          convertMatchers(nf.Matchers),
+			Extractors:        convertExtractors(nf.Extractors),
+		}
+		for _, ext := range nf.Extensions {
+			ext = strings.ToLower(strings.TrimSpace(ext))
+			if ext == "all" {
+				req.Extensions = nil
+				break
+			}
+			if ext != "" && !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			req.Extensions = append(req.Extensions, ext)
+		}
+		if len(nf.Extensions) == 0 {
+			req.DenyList = append(req.DenyList, defaultFileDenylist...)
+		}
+		if nf.MaxSize != "" {
+			size, err := parseByteSize(nf.MaxSize)
+			if err != nil {
+				return nil, err
+			}
+			req.MaxSize = size
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// parseByteSize 解析 max-size（如 1024、512kb、5Mb、1GB）
+func parseByteSize(s string) (int64, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	num := strings.TrimRightFunc(v, unicode.IsLetter)
+	unit := strings.TrimSpace(v[len(num):])
+	n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("无效的 max-size: %s", s)
+	}
+	multipliers := map[string]float64{"": 1, "b": 1, "k": 1 << 10, "kb": 1 << 10, "m": 1 << 20, "mb": 1 << 20, "g": 1 << 30, "gb": 1 << 30}
+	m, ok := multipliers[unit]
+	if !ok {
+		return 0, fmt.Errorf("无效的 max-size 单位: %s", s)
+	}
+	return int64(n * m), nil
+}
+
+// executeFileTemplate 扫描本地目录 / 文件 / 压缩包，每个命中的文件生成一个结果（带文件路径和行号）
+// 非本地路径的目标直接跳过
+func (s *Scanner) executeFileTemplate(ctx context.Context, job *ScanJob, info *targetInfo, template models.POCTemplate, requests []FileRequest) []*models.ScanResult {
+	if info.LocalPath == "" {
+		return nil
+	}
+	host := info.LocalPath
+	base, err := s.newProtocolContext(job, info, template, 1)
+	if err != nil {
+		return []*models.ScanResult{newErrorResult(template, host, err.Error())}
+	}
+
+	var results []*models.ScanResult
+	for _, req := range requests {
+		err := walkFileTarget(ctx, info.LocalPath, req, func(path string, data []byte) {
+			if r := s.matchFile(base, template, host, req, path, string(data)); r != nil {
+				results = append(results, r)
+			}
+		})
+		if err != nil {
+			results = append(results, newErrorResult(template, host, err.Error()))
+		}
+	}
+	return finishResults(results, template, host, nil)
+}
+
+// matchFile 对单个文件执行 matcher 和提取器
+// 每个文件使用独立的上下文副本，避免文件内容累积到历史响应中
+func (s *Scanner) matchFile(base *templateContext, template models.POCTemplate, host string, req FileRequest, path, content string) *models.ScanResult {
+	fctx := *base
+	fctx.history = make(map[string]interface{})
+	fctx.extracted = make(map[string][]string)
+	fctx.vars = make(map[string]interface{}, len(base.vars))
+	for k, v := range base.vars {
+		fctx.vars[k] = v
+	}
+
+	event := map[string]interface{}{
+		"body": content,
+		"raw":  content,
+		"data": content,
+		"path": path,
+		"file": filepath.Base(path),
+	}
+	r := s.matchProtocolEvent(&fctx, template, host, req.Matchers, req.MatchersCondition, req.Extractors, event, "file: "+path, "")
+	if r == nil {
+		return nil
+	}
+
+	var values []string
+	for _, vs := range fctx.extracted {
+		values = append(values, vs...)
+	}
+	r.FilePath = path
+	r.Lines = matchedLines(content, req.Matchers, values)
+	r.Response = lineSnippet(content, r.Lines)
+	return r
+}
+
+// walkFileTarget 遍历目标下符合条件的文件，逐个读取后回调
+func walkFileTarget(ctx context.Context, root string, req FileRequest, fn func(path string, data []byte)) error {
+	st, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("无法访问目标路径: %v", err)
+	}
+	if !st.IsDir() {
+		if isArchiveFile(root) {
+			return walkArchive(ctx, root, req, fn)
+		}
+		if allowedFile(root, req) && st.Size() <= req.MaxSize {
+			if data, err := os.ReadFile(root); err == nil {
+				fn(root, data)
+			}
+		}
+		return nil
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// 无权限等错误只跳过当前项
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (req.NoRecursive || deniedPath(path, req.DenyList)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if isArchiveFile(path) {
+			if req.Archive && !deniedPath(path, req.DenyList) {
+				walkArchive(ctx, path, req, fn)
+			}
+			return nil
+		}
+		if !allowedFile(path, req) {
+			return nil
+		}
+		if fi, err := d.Info(); err != nil || fi.Size() > req.MaxSize {
+			return nil
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			fn(path, data)
+		}
+		return nil
+	})
+}
+
+// walkArchive 遍历压缩包（zip / jar / war、tar、tar.gz / tgz、gz）中符合条件的文件
+// 文件路径形如 app.zip!/src/config.php；不处理嵌套的压缩包
+func walkArchive(ctx context.Context, archive string, req FileRequest, fn func(path string, data []byte)) error {
+	lower := strings.ToLower(archive)
+	visit := func(name string, size int64, open func() (io.ReadCloser, error)) {
+		name = filepath.ToSlash(name)
+		if ctx.Err() != nil || isArchiveFile(name) || !allowedFile(name, req) || size > req.MaxSize {
+			return
+		}
+		rc, err := open()
+		if err != nil {
+			return
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(io.LimitReader(rc, req.MaxSize+1))
+		if err != nil || int64(len(data)) > req.MaxSize {
+			return
+		}
+		fn(archive+"!/"+strings.TrimPrefix(name, "/"), data)
+	}
+
+	switch {
+	case strings.HasSuffix(lower, ".zip") || strings.HasSuffix(lower, ".jar") || strings.HasSuffix(lower, ".war"):
+		zr, err := zip.OpenReader(archive)
+		if err != nil {
+			return fmt.Errorf("无法读取压缩包 %s: %v", archive, err)
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			visit(f.Name, int64(f.UncompressedSize64), f.Open)
+		}
+		return nil
+
+	case strings.HasSuffix(lower, ".tar") || strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz"):
+		f, err := os.Open(archive)
+		if err != nil {
+			return fmt.Errorf("无法读取压缩包 %s: %v", archive, err)
+		}
+		defer f.Close()
+		var r io.Reader = f
+		if !strings.HasSuffix(lower, ".tar") {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				return fmt.Errorf("无法读取压缩包 %s: %v", archive, err)
+			}
+			defer gz.Close()
+			r = gz
+		}
+		tr := tar.NewReader(r)
+		for {
+			h, err := tr.Next()
+			if err != nil {
+				break
+			}
+			if h.Typeflag != tar.TypeReg {
+				continue
+			}
+			visit(h.Name, h.Size, func() (io.ReadCloser, error) { return io.NopCloser(tr), nil })
+		}
+		return nil
+
+	case strings.HasSuffix(lower, ".gz"):
+		f, err := os.Open(archive)
+		if err != nil {
+			return fmt.Errorf("无法读取压缩包 %s: %v", archive, err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("无法读取压缩包 %s: %v", archive, err)
+		}
+		defer gz.Close()
+		visit(strings.TrimSuffix(filepath.Base(archive), filepath.Ext(archive)), 0, func() (io.ReadCloser, error) { return io.NopCloser(gz), nil })
+		return nil
+	}
+	return nil
+}
+
+// isArchiveFile 按扩展名判断是否为支持的压缩包
+func isArchiveFile(path string) bool {
+	lower := strings.ToLower(path)
+	for _, ext := range []string{".zip", ".jar", ".war", ".tar", ".tar.gz", ".tgz", ".gz"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedFile 检查扩展名白名单和排除列表
+func allowedFile(path string, req FileRequest) bool {
+	if deniedPath(path, req.DenyList) {
+		return false
+	}
+	if len(req.Extensions) == 0 {
+		return true
+	}
+	lower := strings.ToLower(path)
+	for _, ext := range req.Extensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// deniedPath 排除列表：.xxx 按扩展名匹配，其余按文件 / 目录名或路径片段匹配
+func deniedPath(path string, denylist []string) bool {
+	slashed := filepath.ToSlash(path)
+	lower := strings.ToLower(slashed)
+	name := strings.ToLower(filepath.Base(path))
+	for _, entry := range denylist {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case strings.HasPrefix(entry, ".") && !strings.Contains(entry, "/"):
+			if strings.HasSuffix(name, entry) || name == entry {
+				return true
+			}
+		case strings.Contains(entry, "/"):
+			if strings.Contains(lower, entry) {
+				return true
+			}
+		case name == entry:
+			return true
+		}
+	}
+	return false
+}
+
+// matchedLines 计算 word / regex matcher 及提取值在内容中出现的行号（升序去重，最多 maxFileLines 个）
+func matchedLines(content string, matchers []Matcher, values []string) []int {
+	var offsets []int
+	find := func(haystack, needle string) {
+		if needle == "" {
+			return
+		}
+		for start := 0; ; {
+			i := strings.Index(haystack[start:], needle)
+			if i < 0 {
+				return
+			}
+			offsets = append(offsets, start+i)
+			start += i + len(needle)
+		}
+	}
+
+	for _, m := range matchers {
+		if m.Negative {
+			continue
+		}
+		switch m.Type {
+		case "word", "words":
+			haystack := content
+			if m.CaseInsensitive {
+				haystack = strings.ToLower(content)
+			}
+			for _, w := range m.Words {
+				if m.CaseInsensitive {
+					w = strings.ToLower(w)
+				}
+				find(haystack, w)
+			}
+		case "regex":
+			for _, pattern := range m.Regex {
+				re, err := compileRegexCached(pattern)
+				if err != nil {
+					continue
+				}
+				for _, loc := range re.FindAllStringIndex(content, -1) {
+					offsets = append(offsets, loc[0])
+				}
+			}
+		}
+	}
+	for _, v := range values {
+		find(content, v)
+	}
+
+	// 按偏移升序一次扫描换行符换算为行号
+	sort.Ints(offsets)
+	var lines []int
+	line, pos := 1, 0
+	for _, off := range offsets {
+		line += strings.Count(content[pos:off], "\n")
+		pos = off
+		if len(lines) == 0 || lines[len(lines)-1] != line {
+			lines = append(lines, line)
+		}
+		if len(lines) >= maxFileLines {
+			break
+		}
+	}
+	return lines
+}
+
+// lineSnippet 展示用：列出命中的行（行号: 内容）
+func lineSnippet(content string, lines []int) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	all := strings.Split(content, "\n")
+	var sb strings.Builder
+	for _, n := range lines {
+		if n-1 >= len(all) {
+			continue
+		}
+		text := strings.TrimRight(all[n-1], "\r")
+		if len(text) > 300 {
+			text = text[:300] + "..."
+		}
+		fmt.Fprintf(&sb, "%d: %s\n", n, text)
+	}
+	return sb.String()
+}
@@ -0,0 +1,298 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"nuclei-poc-manager/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultReadSize 网络请求默认读取的字节数
+const DefaultReadSize = 1024
+
+// maxReadAll read-all 模式下最多读取的字节数
+const maxReadAll = 1 << 20
+
+// NetworkInput 网络请求中的单次发送
+type NetworkInput struct {
+	Data string // 发送内容（支持变量）
+	Type string // text（默认）或 hex
+	Read int    // 发送后立即读取的字节数（0=不读取）
+	Name string // 读取结果的 part 名称
+}
+
+// NetworkRequest 网络（TCP/TLS）请求配置
+type NetworkRequest struct {
+	Hosts             []string // 连接地址（支持变量，tls:// 前缀表示使用 TLS）
+	Ports             []string // 地址中未指定端口时使用的端口
+	Inputs            []NetworkInput
+	ReadSize          int  // 全部发送完成后读取的字节数
+	ReadAll           bool // 读取到连接关闭或超时为止
+	Matchers          []Matcher
+	MatchersCondition string
+	Extractors        []Extractor
+}
+
+// nucleiNetworkInput Nuclei 网络请求 inputs 结构
+type nucleiNetworkInput struct {
+	Data string `yaml:"data"`
+	Type string `yaml:"type"`
+	Read int    `yaml:"read"`
+	Name string `yaml:"name"`
+}
+
+// nucleiNetworkReq Nuclei 网络请求结构（network: / tcp:）
+type nucleiNetworkReq struct {
+	Host              []string             `yaml:"host"`
+	Port              string               `yaml:"port"`
+	Inputs            []nucleiNetworkInput `yaml:"inputs"`
+	ReadSize          int                  `yaml:"read-size"`
+	ReadAll           bool                 `yaml:"read-all"`
+	MatchersCondition string               `yaml:"matchers-condition"`
+	Matchers          []nucleiMatcher      `yaml:"matchers"`
+	Extractors        []nucleiExtractor    `yaml:"extractors"`
+}
+
+// parseNetworkRequests 解析模板中的 network / tcp 块（matcher / extractor 默认 part 为 data）
+func parseNetworkRequests(content string) ([]NetworkRequest, error) {
+	var nt nucleiYAML
+	if err := yaml.Unmarshal([]byte(content), &nt); err != nil {
+		return nil, fmt.Errorf("YAML 解析失败: %w", err)
+	}
+
+	var requests []NetworkRequest
+	for _, nr := range append(nt.Network, nt.TCP...) {
+		req := NetworkRequest{
+			Hosts:             nr.Host,
+			ReadSize:          nr.ReadSize,
+			ReadAll:           nr.ReadAll,
+			MatchersCondition: nr.MatchersCondition,
+			Matchers:          convertMatchers(nr.Matchers),
+			Extractors:        convertExtractors(nr.Extractors),
+		}
+		if len(req.Hosts) == 0 {
+			req.Hosts = []string{"{{Hostname}}"}
+		}
+		if req.ReadSize <= 0 {
+			req.ReadSize = DefaultReadSize
+		}
+		for _, p := range strings.Split(nr.Port, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				req.Ports = append(req.Ports, p)
+			}
+		}
+		for _, in := range nr.Inputs {
+			req.Inputs = append(req.Inputs, NetworkInput{
+				Data: in.Data,
+				Type: strings.ToLower(in.Type),
+				Read: in.Read,
+				Name: in.Name,
+			})
+		}
+		for i := range req.Matchers {
+			if req.Matchers[i].Part == "" {
+				req.Matchers[i].Part = "data"
+			}
+		}
+		for i := range req.Extractors {
+			if req.Extractors[i].Part == "" {
+				req.Extractors[i].Part = "data"
+			}
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// networkAddresses 展开请求的连接地址：地址自带端口时直接使用，否则与 port 逐一组合
+func networkAddresses(req NetworkRequest, vars map[string]interface{}) ([]string, error) {
+	var addrs []string
+	for _, h := range req.Hosts {
+		expanded, err := expandVariables(h, vars)
+		if err != nil {
+			return nil, err
+		}
+		prefix := ""
+		if strings.HasPrefix(expanded, "tls://") {
+			prefix, expanded = "tls://", strings.TrimPrefix(expanded, "tls://")
+		}
+		if _, _, err := net.SplitHostPort(expanded); err == nil {
+			addrs = append(addrs, prefix+expanded)
+			continue
+		}
+		if len(req.Ports) == 0 {
+			return nil, fmt.Errorf("网络请求地址缺少端口: %s", expanded)
+		}
+		for _, p := range req.Ports {
+			addrs = append(addrs, prefix+net.JoinHostPort(strings.Trim(expanded, "[]"), p))
+		}
+	}
+	return addrs, nil
+}
+
+// executeNetworkTemplate 依次执行模板中的网络请求（每个连接地址单独匹配）
+// 结果的主机统一为目标 BaseURL（与未命中、失败结果一致，对比扫描按此匹配），连接地址记录在请求中
+func (s *Scanner) executeNetworkTemplate(ctx context.Context, job *ScanJob, info *targetInfo, template models.POCTemplate, requests []NetworkRequest) []*models.ScanResult {
+	host := info.BaseURL
+	tctx, err := s.newProtocolContext(job, info, template, len(requests))
+	if err != nil {
+		return []*models.ScanResult{newErrorResult(template, host, err.Error())}
+	}
+
+	var results []*models.ScanResult
+	var lastErr error
+	for _, req := range requests {
+		addrs, err := networkAddresses(req, tctx.variables())
+		if err != nil {
+			results = append(results, newErrorResult(template, host, err.Error()))
+			return results
+		}
+		for _, addr := range addrs {
+			if ctx.Err() != nil {
+				return finishResults(results, template, host, lastErr)
+			}
+			if err := jobLimiter(job).wait(ctx); err != nil {
+				return finishResults(results, template, host, lastErr)
+			}
+			ex, err := sendNetworkRequest(ctx, req, addr, tctx.variables(), jobTimeout(job))
+			if err != nil {
+				// 单个地址连接失败不影响其他地址
+				lastErr = err
+				continue
+			}
+			if r := s.matchProtocolEvent(tctx, template, host, req.Matchers, req.MatchersCondition, req.Extractors, ex.event, ex.request, ex.response); r != nil {
+				results = append(results, r)
+			}
+		}
+	}
+	results = append(results, s.resolveOASTChecks(ctx, tctx)...)
+	return finishResults(results, template, host, lastErr)
+}
+
+// sendNetworkRequest 连接地址，按顺序发送 inputs 并读取响应
+// 事件中 data 为最后读取的内容，raw 为全部读取内容，命名 input 的读取结果以其 name 作为 part
+func sendNetworkRequest(ctx context.Context, req NetworkRequest, addr string, vars map[string]interface{}, timeout time.Duration) (*httpExchange, error) {
+	useTLS := strings.HasPrefix(addr, "tls://")
+	addr = strings.TrimPrefix(addr, "tls://")
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("设置超时失败: %v", err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if useTLS {
+		serverName, _, _ := net.SplitHostPort(addr)
+		// 服务探测不校验证书（自签名证书很常见）
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("TLS 握手失败: %v", err)
+		}
+		conn = tlsConn
+	}
+
+	event := map[string]interface{}{"host": addr}
+	var sent, received strings.Builder
+	start := time.Now()
+	for _, in := range req.Inputs {
+		data, err := expandVariables(in.Data, vars)
+		if err != nil {
+			return nil, err
+		}
+		if in.Type == "hex" {
+			decoded, err := hex.DecodeString(strings.TrimSpace(data))
+			if err != nil {
+				return nil, fmt.Errorf("无效的 hex 输入: %v", err)
+			}
+			data = string(decoded)
+		}
+		if _, err := io.WriteString(conn, data); err != nil {
+			return nil, fmt.Errorf("发送失败: %v", err)
+		}
+		sent.WriteString(data)
+
+		if in.Read > 0 {
+			chunk, err := readNetwork(conn, in.Read, false)
+			if err != nil && chunk == "" {
+				return nil, fmt.Errorf("读取响应失败: %v", err)
+			}
+			received.WriteString(chunk)
+			if in.Name != "" {
+				event[in.Name] = chunk
+			}
+		}
+	}
+
+	data, err := readNetwork(conn, req.ReadSize, req.ReadAll)
+	if err != nil && data == "" && received.Len() == 0 {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	received.WriteString(data)
+
+	event["data"] = data
+	event["raw"] = received.String()
+	event["request"] = sent.String()
+	event["duration"] = time.Since(start).Seconds()
+
+	response := printableBytes(received.String())
+	if len(response) > 4096 {
+		response = response[:4096] + "\n... (truncated)"
+	}
+	return &httpExchange{
+		event:    event,
+		request:  addr + "\n\n" + printableBytes(sent.String()),
+		response: response,
+	}, nil
+}
+
+// readNetwork 读取网络响应：默认单次读取最多 size 字节，all=true 时读取到连接关闭或超时
+func readNetwork(conn net.Conn, size int, all bool) (string, error) {
+	if !all {
+		buf := make([]byte, size)
+		n, err := conn.Read(buf)
+		return string(buf[:n]), err
+	}
+	data, err := io.ReadAll(io.LimitReader(conn, maxReadAll))
+	if errors.Is(err, os.ErrDeadlineExceeded) && len(data) > 0 {
+		err = nil
+	}
+	return string(data), err
+}
+
+// printableBytes 展示用：二进制内容转换为 hex dump
+func printableBytes(s string) string {
+	if utf8.ValidString(s) && !strings.ContainsRune(s, 0) {
+		return s
+	}
+	return hex.Dump([]byte(s))
+}
+
+// networkRequestCount 网络请求在单个目标上的连接数（按 host × port 估算）
+func networkRequestCount(req NetworkRequest) int {
+	ports := len(req.Ports)
+	if ports == 0 {
+		ports = 1
+	}
+	return len(req.Hosts) * ports
+}
@@ -0,0 +1,232 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+	"nuclei-poc-manager/internal/oast"
+)
+
+// oastVariable 模板中引用带外交互地址的变量名
+const oastVariable = "interactsh-url"
+
+// oastPollInterval 等待交互时的轮询间隔
+const oastPollInterval = 250 * time.Millisecond
+
+// SetOAST 设置（或清除）内置带外交互服务
+func (s *Scanner) SetOAST(srv *oast.Server) {
+	s.mu.Lock()
+	s.oast = srv
+	s.mu.Unlock()
+}
+
+// getOAST 返回当前的带外交互服务（未启用时为 nil）
+func (s *Scanner) getOAST() *oast.Server {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.oast
+}
+
+// requestUsesOAST 判断请求是否引用了 {{interactsh-url}}
+func requestUsesOAST(req HTTPRequest, input string) bool {
+	if strings.Contains(input, oastVariable) || strings.Contains(req.Body, oastVariable) {
+		return true
+	}
+	for _, v := range req.Headers {
+		if strings.Contains(v, oastVariable) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchersUseOAST 判断 matcher 是否依赖带外交互（interactsh_protocol / interactsh_request 等）
+func matchersUseOAST(matchers []Matcher) bool {
+	for _, m := range matchers {
+		if strings.HasPrefix(m.Part, "interactsh") {
+			return true
+		}
+		for _, expr := range m.DSL {
+			if strings.Contains(expr, "interactsh_") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// variablesUseOAST 判断 variables 块是否引用了 {{interactsh-url}}
+func variablesUseOAST(decl map[string]string) bool {
+	for _, v := range decl {
+		if strings.Contains(v, oastVariable) {
+			return true
+		}
+	}
+	return false
+}
+
+// bindTemplateOASTURL 生成模板级的 interactsh-url 写入 tctx.vars，需在解析 variables 块之前调用
+// 引用了它的变量和请求共用这个地址，它的交互对模板的每个请求都有效
+func (s *Scanner) bindTemplateOASTURL(tctx *templateContext) error {
+	srv := s.getOAST()
+	if srv == nil {
+		return fmt.Errorf("%w: 模板使用了 {{%s}}，但未启用 OAST 服务", errUnresolvedVariable, oastVariable)
+	}
+	id, url := srv.NewURL(tctx.jobID())
+	tctx.vars[oastVariable] = url
+	tctx.oastIDs = append(tctx.oastIDs, id)
+	return nil
+}
+
+// bindOASTURL 为单个请求生成新的 interactsh-url 写入 vars，返回关联 ID
+func (s *Scanner) bindOASTURL(owner string, vars map[string]interface{}, req HTTPRequest, input string) ([]string, error) {
+	if !requestUsesOAST(req, input) {
+		return nil, nil
+	}
+	srv := s.getOAST()
+	if srv == nil {
+		return nil, fmt.Errorf("%w: 模板使用了 {{%s}}，但未启用 OAST 服务", errUnresolvedVariable, oastVariable)
+	}
+	id, url := srv.NewURL(owner)
+	vars[oastVariable] = url
+	return []string{id}, nil
+}
+
+// withInteraction 将一次交互合并到响应数据中，供 matcher 使用
+func withInteraction(event map[string]interface{}, it oast.Interaction) map[string]interface{} {
+	merged := make(map[string]interface{}, len(event)+4)
+	for k, v := range event {
+		merged[k] = v
+	}
+	merged["interactsh_protocol"] = it.Protocol
+	merged["interactsh_request"] = it.RawRequest
+	merged["interactsh_response"] = it.RawResponse
+	merged["interactsh_ip"] = it.RemoteAddress
+	return merged
+}
+
+// oastCheck 已发送、要等目标回连后才能确定是否命中的请求
+type oastCheck struct {
+	ids      []string
+	matchers []Matcher
+	event    map[string]interface{}
+	cond     string
+	result   *models.ScanResult // 命中时的结果（请求、响应和提取数据在发送时记录）
+}
+
+// resolveOASTChecks 模板的请求全部发送后统一等待一次冷却时间，返回因交互命中的结果
+// 每个模板只等待一次，payload 组合再多也不会让 worker 阻塞多个冷却时间；仍未命中的请求登记延迟交互回调
+func (s *Scanner) resolveOASTChecks(ctx context.Context, tctx *templateContext) []*models.ScanResult {
+	checks := tctx.oastChecks
+	tctx.oastChecks = nil
+	if len(checks) == 0 {
+		return nil
+	}
+	var results []*models.ScanResult
+	for i, hit := range s.waitInteractions(ctx, checks) {
+		if hit.info == "" {
+			s.watchLateInteractions(tctx.jobID(), checks[i])
+			continue
+		}
+		result := checks[i].result
+		result.Matched = hit.info
+		result.MatcherNames = hit.names
+		results = append(results, result)
+	}
+	return results
+}
+
+// waitInteractions 在冷却时间内轮询交互，逐个代入对应请求的 matcher
+// 返回每个请求的命中信息（未命中为空），全部命中后提前返回
+func (s *Scanner) waitInteractions(ctx context.Context, checks []oastCheck) []matchHit {
+	hits := make([]matchHit, len(checks))
+	srv := s.getOAST()
+	if srv == nil {
+		return hits
+	}
+	deadline := time.Now().Add(srv.Cooldown())
+	seen := make([][]int, len(checks)) // 每个请求的每个 ID 已检查过的交互数
+	for i, c := range checks {
+		seen[i] = make([]int, len(c.ids))
+	}
+	remaining := len(checks)
+	for {
+		for i, c := range checks {
+			if hits[i].info != "" {
+				continue
+			}
+			for j, id := range c.ids {
+				interactions := srv.Interactions(id)
+				for _, it := range interactions[seen[i][j]:] {
+					if matched, hit := checkMatchers(c.matchers, withInteraction(c.event, it), c.cond); matched {
+						hits[i] = hit
+						remaining--
+						break
+					}
+				}
+				seen[i][j] = len(interactions)
+				if hits[i].info != "" {
+					break
+				}
+			}
+		}
+		if remaining == 0 || time.Now().After(deadline) {
+			return hits
+		}
+		select {
+		case <-ctx.Done():
+			return hits
+		case <-time.After(oastPollInterval):
+		}
+	}
+}
+
+// watchLateInteractions 冷却时间结束后仍未命中时登记回调：
+// 保留期内到达的交互若使 matcher 命中，结果追加到所属扫描（每个请求最多追加一次）
+func (s *Scanner) watchLateInteractions(jobID string, c oastCheck) {
+	srv := s.getOAST()
+	if srv == nil {
+		return
+	}
+	var once sync.Once
+	srv.Watch(c.ids, func(it oast.Interaction) {
+		matched, hit := checkMatchers(c.matchers, withInteraction(c.event, it), c.cond)
+		if !matched {
+			return
+		}
+		once.Do(func() {
+			late := *c.result
+			late.ID = fmt.Sprintf("%d", time.Now().UnixNano())
+			late.Matched = hit.info + "; 延迟交互"
+			late.MatcherNames = hit.names
+			late.Timestamp = time.Now()
+			s.addLateResult(jobID, &late)
+		})
+	})
+}
+
+// addLateResult 追加扫描结束（或请求等待期）之后才确认的结果
+func (s *Scanner) addLateResult(scanID string, result *models.ScanResult) {
+	s.mu.Lock()
+	job, ok := s.scans[scanID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	result.ScanID = scanID
+	s.results[scanID] = append(s.results[scanID], *result)
+	job.Status.Found++
+	running := job.Status.Status == "running"
+	s.mu.Unlock()
+
+	s.appendResultLog(scanID, resultLogEntry{Target: -1, Results: []models.ScanResult{*result}})
+
+	// 运行中的扫描结束时会统一保存
+	if !running {
+		s.saveScanToDisk(scanID)
+	}
+}
@@ -0,0 +1,226 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// 攻击模式（与 nuclei 一致）
+const (
+	AttackBatteringRam = "batteringram" // 所有位置使用同一个 payload
+	AttackPitchfork    = "pitchfork"    // 各 payload 列表按下标一一对应
+	AttackClusterBomb  = "clusterbomb"  // 所有 payload 列表的笛卡尔积
+)
+
+// loadPayloads 解析 payloads 定义：内联列表直接使用，字符串视为字典文件路径
+// 相对路径依次相对于模板所在目录、模板根目录查找
+func loadPayloads(raw map[string]interface{}, templatePath, templatesDir string) (map[string][]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	payloads := make(map[string][]string, len(raw))
+	for name, def := range raw {
+		switch v := def.(type) {
+		case []interface{}:
+			values := make([]string, 0, len(v))
+			for _, item := range v {
+				values = append(values, toDSLString(item))
+			}
+			payloads[name] = values
+		case string:
+			values, err := readPayloadFile(v, templatePath, templatesDir)
+			if err != nil {
+				return nil, fmt.Errorf("payload %s 加载失败: %v", name, err)
+			}
+			payloads[name] = values
+		default:
+			return nil, fmt.Errorf("payload %s 格式不支持", name)
+		}
+	}
+	return payloads, nil
+}
+
+// readPayloadFile 按行读取字典文件（忽略空行）
+func readPayloadFile(path, templatePath, templatesDir string) ([]string, error) {
+	resolved := resolvePayloadPath(path, templatePath, templatesDir)
+	if resolved == "" {
+		return nil, fmt.Errorf("字典文件不存在: %s", path)
+	}
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var values []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		values = append(values, line)
+	}
+	return values, sc.Err()
+}
+
+// resolvePayloadPath 查找字典文件实际路径，找不到返回空字符串
+func resolvePayloadPath(path, templatePath, templatesDir string) string {
+	candidates := []string{}
+	if filepath.IsAbs(path) {
+		candidates = append(candidates, path)
+	} else {
+		if templatePath != "" {
+			candidates = append(candidates, filepath.Join(filepath.Dir(templatePath), path))
+		}
+		if templatesDir != "" {
+			candidates = append(candidates, filepath.Join(templatesDir, path))
+		}
+	}
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && !info.IsDir() {
+			return c
+		}
+	}
+	return ""
+}
+
+// generatePayloadCombos 按攻击模式生成 payload 组合，每个组合对应一次请求
+// 未指定攻击模式时：单个 payload 使用 batteringram，多个 payload 使用 clusterbomb
+func generatePayloadCombos(payloads map[string][]string, attack string) ([]map[string]interface{}, error) {
+	if len(payloads) == 0 {
+		return nil, nil
+	}
+
+	// 固定顺序，保证组合顺序稳定
+	names := make([]string, 0, len(payloads))
+	for name := range payloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attack = strings.ToLower(strings.TrimSpace(attack))
+	if attack == "" {
+		attack = AttackBatteringRam
+		if len(names) > 1 {
+			attack = AttackClusterBomb
+		}
+	}
+
+	var combos []map[string]interface{}
+	switch attack {
+	case AttackBatteringRam:
+		// 所有 payload 位置同时填入第一个列表中的同一个值
+		for _, v := range payloads[names[0]] {
+			combo := make(map[string]interface{}, len(names))
+			for _, name := range names {
+				combo[name] = v
+			}
+			combos = append(combos, combo)
+		}
+	case AttackPitchfork:
+		size := len(payloads[names[0]])
+		for _, name := range names[1:] {
+			if len(payloads[name]) != size {
+				return nil, fmt.Errorf("pitchfork 模式要求所有 payload 数量一致（%s: %d, %s: %d）", names[0], size, name, len(payloads[name]))
+			}
+		}
+		for i := 0; i < size; i++ {
+			combo := make(map[string]interface{}, len(names))
+			for _, name := range names {
+				combo[name] = payloads[name][i]
+			}
+			combos = append(combos, combo)
+		}
+	case AttackClusterBomb:
+		combos = []map[string]interface{}{{}}
+		for _, name := range names {
+			next := make([]map[string]interface{}, 0, len(combos)*len(payloads[name]))
+			for _, base := range combos {
+				for _, v := range payloads[name] {
+					combo := make(map[string]interface{}, len(base)+1)
+					for k, bv := range base {
+						combo[k] = bv
+					}
+					combo[name] = v
+					next = append(next, combo)
+				}
+			}
+			combos = next
+		}
+	default:
+		return nil, fmt.Errorf("不支持的攻击模式: %s", attack)
+	}
+	return combos, nil
+}
+
+// requestPayloadCombos 加载请求块的 payloads 并生成组合（未定义 payloads 时返回 nil）
+func requestPayloadCombos(req HTTPRequest, templatePath, templatesDir string) ([]map[string]interface{}, error) {
+	payloads, err := loadPayloads(req.Payloads, templatePath, templatesDir)
+	if err != nil || len(payloads) == 0 {
+		return nil, err
+	}
+	return generatePayloadCombos(payloads, req.AttackType)
+}
+
+// payloadCache 扫描期间已生成的 payload 组合：同一模板的字典文件每次扫描只读取一次，所有目标共用
+// 组合在执行时只读，可以在多个目标之间共享
+type payloadCache struct {
+	mu      sync.Mutex
+	entries map[string]*payloadEntry
+}
+
+// payloadEntry 单个请求块的 payload 组合（once 保证并发的目标也只加载一次）
+type payloadEntry struct {
+	once   sync.Once
+	combos []map[string]interface{}
+	err    error
+}
+
+func newPayloadCache() *payloadCache {
+	return &payloadCache{entries: make(map[string]*payloadEntry)}
+}
+
+// combos 返回请求块的 payload 组合，首次调用时加载（c 为 nil 时每次都重新加载）
+func (c *payloadCache) combos(req HTTPRequest, template models.POCTemplate, templatesDir string) ([]map[string]interface{}, error) {
+	if len(req.Payloads) == 0 {
+		return nil, nil
+	}
+	if c == nil {
+		return requestPayloadCombos(req, template.FilePath, templatesDir)
+	}
+	// fmt 按键排序输出 map，相同的 payloads 定义得到相同的键
+	key := fmt.Sprintf("%s\x00%s\x00%s\x00%v", template.ID, template.FilePath, req.AttackType, req.Payloads)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &payloadEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.combos, entry.err = requestPayloadCombos(req, template.FilePath, templatesDir)
+	})
+	return entry.combos, entry.err
+}
+
+// payloadData 将 payload 组合转换为结果中的提取数据
+func payloadData(combo map[string]interface{}) map[string]models.ExtractedValues {
+	if len(combo) == 0 {
+		return nil
+	}
+	data := make(map[string]models.ExtractedValues, len(combo))
+	for k, v := range combo {
+		data[k] = models.ExtractedValues{toDSLString(v)}
+	}
+	return data
+}
@@ -0,0 +1,227 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// combo 构造测试期望的 payload 组合
+func combo(kv ...string) map[string]interface{} {
+	m := make(map[string]interface{}, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		m[kv[i]] = kv[i+1]
+	}
+	return m
+}
+
+func TestGeneratePayloadCombos(t *testing.T) {
+	users := []string{"admin", "root"}
+	passes := []string{"123456", "toor"}
+	tests := []struct {
+		name     string
+		payloads map[string][]string
+		attack   string
+		want     []map[string]interface{}
+	}{
+		{
+			name:     "empty",
+			payloads: nil,
+			attack:   AttackClusterBomb,
+			want:     nil,
+		},
+		{
+			name:     "batteringram single",
+			payloads: map[string][]string{"user": users},
+			attack:   AttackBatteringRam,
+			want:     []map[string]interface{}{combo("user", "admin"), combo("user", "root")},
+		},
+		{
+			// 所有位置使用按名称排序后第一个列表中的同一个值
+			name:     "batteringram multiple",
+			payloads: map[string][]string{"user": users, "pass": passes},
+			attack:   AttackBatteringRam,
+			want: []map[string]interface{}{
+				combo("pass", "123456", "user", "123456"),
+				combo("pass", "toor", "user", "toor"),
+			},
+		},
+		{
+			name:     "pitchfork",
+			payloads: map[string][]string{"user": users, "pass": passes},
+			attack:   AttackPitchfork,
+			want: []map[string]interface{}{
+				combo("user", "admin", "pass", "123456"),
+				combo("user", "root", "pass", "toor"),
+			},
+		},
+		{
+			name:     "clusterbomb",
+			payloads: map[string][]string{"user": users, "pass": passes},
+			attack:   AttackClusterBomb,
+			want: []map[string]interface{}{
+				combo("pass", "123456", "user", "admin"),
+				combo("pass", "123456", "user", "root"),
+				combo("pass", "toor", "user", "admin"),
+				combo("pass", "toor", "user", "root"),
+			},
+		},
+		{
+			name:     "clusterbomb three lists",
+			payloads: map[string][]string{"a": {"1", "2"}, "b": {"x"}, "c": {"p", "q"}},
+			attack:   AttackClusterBomb,
+			want: []map[string]interface{}{
+				combo("a", "1", "b", "x", "c", "p"),
+				combo("a", "1", "b", "x", "c", "q"),
+				combo("a", "2", "b", "x", "c", "p"),
+				combo("a", "2", "b", "x", "c", "q"),
+			},
+		},
+		{
+			name:     "clusterbomb with empty list",
+			payloads: map[string][]string{"user": users, "pass": {}},
+			attack:   AttackClusterBomb,
+			want:     []map[string]interface{}{},
+		},
+		{
+			name:     "default single is batteringram",
+			payloads: map[string][]string{"user": users},
+			attack:   "",
+			want:     []map[string]interface{}{combo("user", "admin"), combo("user", "root")},
+		},
+		{
+			name:     "default multiple is clusterbomb",
+			payloads: map[string][]string{"user": {"admin"}, "pass": passes},
+			attack:   "",
+			want: []map[string]interface{}{
+				combo("pass", "123456", "user", "admin"),
+				combo("pass", "toor", "user", "admin"),
+			},
+		},
+		{
+			name:     "attack type is case insensitive",
+			payloads: map[string][]string{"user": users, "pass": passes},
+			attack:   " PitchFork ",
+			want: []map[string]interface{}{
+				combo("user", "admin", "pass", "123456"),
+				combo("user", "root", "pass", "toor"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := generatePayloadCombos(tt.payloads, tt.attack)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGeneratePayloadCombosErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		payloads map[string][]string
+		attack   string
+		wantErr  string
+	}{
+		{
+			name:     "pitchfork length mismatch",
+			payloads: map[string][]string{"user": {"admin", "root"}, "pass": {"123456"}},
+			attack:   AttackPitchfork,
+			wantErr:  "pitchfork",
+		},
+		{
+			name:     "unknown attack",
+			payloads: map[string][]string{"user": {"admin"}},
+			attack:   "sniper",
+			wantErr:  "不支持的攻击模式",
+		},
+	}
+	for _, tt := range tests {
+		_, err := generatePayloadCombos(tt.payloads, tt.attack)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadPayloads(t *testing.T) {
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "http", "login.yaml")
+	os.MkdirAll(filepath.Dir(templatePath), 0755)
+	os.WriteFile(filepath.Join(dir, "http", "users.txt"), []byte("admin\r\n\nroot\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, "wordlists"), 0755)
+	os.WriteFile(filepath.Join(dir, "wordlists", "pass.txt"), []byte("123456\ntoor"), 0644)
+
+	got, err := loadPayloads(map[string]interface{}{
+		"user":  "users.txt",          // 相对于模板所在目录
+		"pass":  "wordlists/pass.txt", // 相对于模板根目录
+		"token": []interface{}{"a", 1, true},
+	}, templatePath, dir)
+	if err != nil {
+		t.Fatalf("loadPayloads error: %v", err)
+	}
+	want := map[string][]string{
+		"user":  {"admin", "root"},
+		"pass":  {"123456", "toor"},
+		"token": {"a", "1", "true"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadPayloads = %v, want %v", got, want)
+	}
+
+	if _, err := loadPayloads(map[string]interface{}{"user": "missing.txt"}, templatePath, dir); err == nil {
+		t.Error("missing payload file should fail")
+	}
+}
+
+func TestPayloadCacheLoadsOnce(t *testing.T) {
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "login.yaml")
+	wordlist := filepath.Join(dir, "users.txt")
+	os.WriteFile(wordlist, []byte("admin\nroot\n"), 0644)
+
+	req := HTTPRequest{Payloads: map[string]interface{}{"user": "users.txt"}}
+	template := models.POCTemplate{ID: "login", FilePath: templatePath}
+	want := []map[string]interface{}{combo("user", "admin"), combo("user", "root")}
+
+	cache := newPayloadCache()
+	got, err := cache.combos(req, template, dir)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("combos = %v, %v, want %v", got, err, want)
+	}
+
+	// 字典文件只在首次使用时读取：删除后同一模板的其他目标仍使用已加载的组合
+	os.Remove(wordlist)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := cache.combos(req, template, dir)
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("cached combos = %v, %v, want %v", got, err, want)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 其他模板和未缓存时重新加载
+	if _, err := cache.combos(req, models.POCTemplate{ID: "other", FilePath: templatePath}, dir); err == nil {
+		t.Error("other template: expected the missing wordlist to fail")
+	}
+	if _, err := (*payloadCache)(nil).combos(req, template, dir); err == nil {
+		t.Error("nil cache: expected the missing wordlist to fail")
+	}
+	if got, err := cache.combos(HTTPRequest{}, template, dir); got != nil || err != nil {
+		t.Errorf("no payloads: combos = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// resultLogEntry 结果日志中的一行：一个任务的全部结果，或一条与任务无关的延迟结果
+// 每行一次写入，进程被杀时最多丢失最后一行（该任务继续扫描时重新执行）
+type resultLogEntry struct {
+	Target   int                 `json:"target"`
+	Unit     string              `json:"unit,omitempty"` // 模板 / 聚类 / 工作流 ID，为空表示延迟结果
+	Requests int                 `json:"requests,omitempty"`
+	Results  []models.ScanResult `json:"results"`
+	Failed   int                 `json:"failed,omitempty"`  // 请求失败的模板数（同一目标只保存第一条错误结果）
+	Checked  []checkedTemplate   `json:"checked,omitempty"` // 收到响应但未命中的已有发现（不保存为结果）
+}
+
+// checkedTemplate 其他扫描中的发现在本次扫描中收到了响应但没有命中，对比扫描时据此确认发现已修复
+// 只记录已有发现的 模板 + 主机，其余未命中的组合不保存
+type checkedTemplate struct {
+	TemplateID string `json:"templateId"`
+	Host       string `json:"host"`
+}
+
+// scanFilePath 扫描状态快照文件
+func (s *Scanner) scanFilePath(scanID string) string {
+	return filepath.Join(s.scansDir, scanID+".json")
+}
+
+// resultLogPath 扫描结果日志文件（追加写入，每行一个 resultLogEntry）
+func (s *Scanner) resultLogPath(scanID string) string {
+	return filepath.Join(s.scansDir, scanID+".results.jsonl")
+}
+
+// appendResultLog 追加一行结果日志
+func (s *Scanner) appendResultLog(scanID string, entry resultLogEntry) error {
+	if s.scansDir == "" {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.diskMu.Lock()
+	defer s.diskMu.Unlock()
+	f, err := os.OpenFile(s.resultLogPath(scanID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readResultLog 读取结果日志，跳过无法解析的行
+// 写入中途退出会在末尾留下不完整的行，将其截掉，避免之后追加的内容接在残行后面
+func readResultLog(path string) ([]resultLogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []resultLogEntry
+	var complete int64 // 最后一个完整行之后的偏移
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				f.Close()
+				return entries, os.Truncate(path, complete)
+			}
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		complete += int64(len(line))
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var entry resultLogEntry
+			if json.Unmarshal(line, &entry) == nil {
+				entries = append(entries, entry)
+			}
+		}
+	}
+}
+
+// writeFileAtomic 先写入同目录下的临时文件再重命名，写入中途退出不会留下损坏的文件
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
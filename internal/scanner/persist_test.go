@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadResultLog(t *testing.T) {
+	const (
+		line0 = `{"target":0,"unit":"t0","requests":1,"results":[{"id":"r0","templateId":"t0","matched":"http://a"}]}` + "\n"
+		line1 = `{"target":1,"unit":"t1","requests":2,"results":[]}` + "\n"
+	)
+	tests := []struct {
+		name     string
+		content  string
+		want     []string // 各条目的 unit
+		wantFile string   // 读取后的文件内容
+	}{
+		{
+			name:     "complete",
+			content:  line0 + line1,
+			want:     []string{"t0", "t1"},
+			wantFile: line0 + line1,
+		},
+		{
+			name:     "empty",
+			content:  "",
+			want:     nil,
+			wantFile: "",
+		},
+		{
+			// 写入中途退出留下的残行被截掉，之后追加的条目不会与残行拼接
+			name:     "truncated tail",
+			content:  line0 + line1 + `{"target":2,"unit":"t2","results":[{"id":"x`,
+			want:     []string{"t0", "t1"},
+			wantFile: line0 + line1,
+		},
+		{
+			name:     "only truncated line",
+			content:  `{"target":0,"unit":"t0"`,
+			want:     nil,
+			wantFile: "",
+		},
+		{
+			// 完整但无法解析的行跳过，不影响后续条目
+			name:     "corrupt line",
+			content:  line0 + "garbage\n\n" + line1,
+			want:     []string{"t0", "t1"},
+			wantFile: line0 + "garbage\n\n" + line1,
+		},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "scan.results.jsonl")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := readResultLog(path)
+		if err != nil {
+			t.Errorf("%s: readResultLog error: %v", tt.name, err)
+			continue
+		}
+		var units []string
+		for _, e := range entries {
+			units = append(units, e.Unit)
+		}
+		if strings.Join(units, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: units = %v, want %v", tt.name, units, tt.want)
+		}
+		data, _ := os.ReadFile(path)
+		if string(data) != tt.wantFile {
+			t.Errorf("%s: file = %q, want %q", tt.name, data, tt.wantFile)
+		}
+	}
+}
+
+func TestReadResultLogMissing(t *testing.T) {
+	if _, err := readResultLog(filepath.Join(t.TempDir(), "missing.results.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("readResultLog on missing file: error = %v, want not exist", err)
+	}
+}
+
+func TestReadResultLogEntryFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.results.jsonl")
+	content := `{"target":3,"unit":"c1","requests":4,"results":[{"id":"r1","templateId":"t1","host":"http://a","matched":"http://a/x"}],"checked":[{"templateId":"t2","host":"http://a"}]}` + "\n"
+	os.WriteFile(path, []byte(content), 0644)
+
+	entries, err := readResultLog(path)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("readResultLog = %v, %v", entries, err)
+	}
+	e := entries[0]
+	if e.Target != 3 || e.Unit != "c1" || e.Requests != 4 {
+		t.Errorf("entry = %+v", e)
+	}
+	if len(e.Results) != 1 || e.Results[0].Matched != "http://a/x" {
+		t.Errorf("results = %+v", e.Results)
+	}
+	if len(e.Checked) != 1 || e.Checked[0] != (checkedTemplate{TemplateID: "t2", Host: "http://a"}) {
+		t.Errorf("checked = %+v", e.Checked)
+	}
+}
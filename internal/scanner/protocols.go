@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// executeProtocolTemplate 执行非 HTTP 协议的模板，handled=false 表示模板不包含这些协议
+func (s *Scanner) executeProtocolTemplate(ctx context.Context, job *ScanJob, info *targetInfo, template models.POCTemplate) ([]*models.ScanResult, bool) {
+	if requests, err := parseFileRequests(template.Content); err == nil && len(requests) > 0 {
+		return s.executeFileTemplate(ctx, job, info, template, requests), true
+	}
+	// 本地路径目标只用于 file 模板，其他模板直接跳过
+	if info.LocalPath != "" {
+		return nil, true
+	}
+	if requests, err := parseDNSRequests(template.Content); err == nil && len(requests) > 0 {
+		return s.executeDNSTemplate(ctx, job, info, template, requests), true
+	}
+	if requests, err := parseNetworkRequests(template.Content); err == nil && len(requests) > 0 {
+		return s.executeNetworkTemplate(ctx, job, info, template, requests), true
+	}
+	if requests, err := parseSSLRequests(template.Content); err == nil && len(requests) > 0 {
+		return s.executeSSLTemplate(ctx, job, info, template, requests), true
+	}
+	if requests, err := parseWebSocketRequests(template.Content); err == nil && len(requests) > 0 {
+		return s.executeWebSocketTemplate(ctx, job, info, template, requests), true
+	}
+	return nil, false
+}
+
+// countProtocolRequests 非 HTTP 协议模板在单个目标上的请求数（不是这类模板时返回 0）
+func countProtocolRequests(content string) int {
+	if requests, err := parseDNSRequests(content); err == nil && len(requests) > 0 {
+		return len(requests)
+	}
+	if requests, err := parseNetworkRequests(content); err == nil && len(requests) > 0 {
+		n := 0
+		for _, req := range requests {
+			n += networkRequestCount(req)
+		}
+		return n
+	}
+	if requests, err := parseSSLRequests(content); err == nil && len(requests) > 0 {
+		return len(requests)
+	}
+	if requests, err := parseWebSocketRequests(content); err == nil && len(requests) > 0 {
+		return len(requests)
+	}
+	if requests, err := parseFileRequests(content); err == nil && len(requests) > 0 {
+		return len(requests)
+	}
+	return 0
+}
+
+// newProtocolContext 创建非 HTTP 协议模板的执行上下文（解析 variables 块）
+// 模板引用 {{interactsh-url}} 时先生成模板级地址，variables 块和各个请求共用
+func (s *Scanner) newProtocolContext(job *ScanJob, info *targetInfo, template models.POCTemplate, steps int) (*templateContext, error) {
+	tctx := newTemplateContext(nil, nil, info)
+	tctx.job = job
+	tctx.chained = steps > 1
+	if strings.Contains(template.Content, oastVariable) {
+		if err := s.bindTemplateOASTURL(tctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := resolveTemplateVariables(parseTemplateVariables(template.Content), tctx.vars); err != nil {
+		return nil, err
+	}
+	return tctx, nil
+}
+
+// matchProtocolEvent 对非 HTTP 协议的单次响应执行提取器和 matcher，命中时返回结果
+// 没有 matcher 时，有提取结果即视为命中（多步模板中只做提取）
+// matcher 依赖带外交互时登记到 tctx.oastChecks，由执行函数在全部请求发送后统一等待
+func (s *Scanner) matchProtocolEvent(tctx *templateContext, template models.POCTemplate, host string, matchers []Matcher, cond string, extractors []Extractor, event map[string]interface{}, request, response string) *models.ScanResult {
+	tctx.record(event)
+	extracted := tctx.applyExtractors(extractors, tctx.matchEvent(event))
+
+	if len(tctx.oastIDs) > 0 && matchersUseOAST(matchers) {
+		pending := newScanResult(template, host)
+		pending.ExtractedData = tctx.resultExtracted()
+		pending.Request = request
+		pending.Response = response
+		tctx.oastChecks = append(tctx.oastChecks, oastCheck{
+			ids:      tctx.oastIDs,
+			matchers: tctx.expandMatchers(matchers),
+			event:    tctx.matchEvent(event),
+			cond:     cond,
+			result:   pending,
+		})
+		return nil
+	}
+
+	var matched bool
+	var hit matchHit
+	if len(matchers) == 0 {
+		if tctx.chained || len(extracted) == 0 {
+			return nil
+		}
+		matched, hit = true, matchHit{info: "extractor"}
+	} else {
+		matched, hit = checkMatchers(tctx.expandMatchers(matchers), tctx.matchEvent(event), cond)
+	}
+	if !matched {
+		return nil
+	}
+
+	result := newScanResult(template, host)
+	result.Matched = hit.info
+	result.MatcherNames = hit.names
+	result.ExtractedData = tctx.resultExtracted()
+	result.Request = request
+	result.Response = response
+	return result
+}
+
+// finishResults 没有任何命中时返回单个错误结果（HTTP 和其他协议模板共用）
+// lastErr 为最后一次请求失败的原因，有请求失败时返回该错误而不是未命中
+func finishResults(results []*models.ScanResult, template models.POCTemplate, host string, lastErr error) []*models.ScanResult {
+	if len(results) > 0 {
+		return results
+	}
+	if lastErr != nil {
+		return []*models.ScanResult{newErrorResult(template, host, lastErr.Error())}
+	}
+	return []*models.ScanResult{newErrorResult(template, host, msgNoMatch)}
+}
+
+// jobLimiter 扫描任务的请求速率限制（nil 表示不限速）
+func jobLimiter(job *ScanJob) *rateLimiter {
+	if job == nil {
+		return nil
+	}
+	return job.limiter
+}
+
+// jobTimeout 扫描任务的单次请求超时
+func jobTimeout(job *ScanJob) time.Duration {
+	if job != nil && job.Options.Timeout > 0 {
+		return time.Duration(job.Options.Timeout) * time.Second
+	}
+	return DefaultTimeout * time.Second
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+func TestProtocolResultsUseTargetHost(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.NotFoundHandler())
+	defer secure.Close()
+	ws := httptest.NewServer(http.HandlerFunc(echoWebSocket))
+	defer ws.Close()
+
+	tests := []struct {
+		name     string
+		target   string
+		addr     string // 请求记录中应包含的实际连接地址
+		template string // %s 为匹配条件
+		match    string
+		noMatch  string
+	}{
+		{
+			name:   "network",
+			target: strings.TrimPrefix(plain.URL, "http://"),
+			addr:   strings.TrimPrefix(plain.URL, "http://"),
+			template: `tcp:
+  - host: ["{{Hostname}}"]
+    inputs:
+      - data: "GET / HTTP/1.0\r\n\r\n"
+    read-size: 256
+    matchers:
+      - type: word
+        words: ["%s"]
+`,
+			match:   "hello",
+			noMatch: "never-matches",
+		},
+		{
+			name:   "ssl",
+			target: secure.URL,
+			addr:   strings.TrimPrefix(secure.URL, "https://"),
+			template: `ssl:
+  - address: "{{Host}}:{{Port}}"
+    matchers:
+      - type: dsl
+        dsl: ["%s"]
+`,
+			match:   "tls_version != ''",
+			noMatch: "tls_version == 'none'",
+		},
+		{
+			name:   "websocket",
+			target: ws.URL,
+			addr:   "ws://" + strings.TrimPrefix(ws.URL, "http://"),
+			template: `websocket:
+  - inputs:
+      - data: hello
+    matchers:
+      - type: word
+        words: ["%s"]
+`,
+			match:   "hello",
+			noMatch: "never-matches",
+		},
+	}
+
+	s := NewScanner("")
+	job := &ScanJob{Options: models.ScanOptions{Timeout: 2}}
+	for _, tt := range tests {
+		info, err := parseTarget(tt.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		run := func(cond string) *models.ScanResult {
+			template := models.POCTemplate{ID: tt.name, Content: "id: " + tt.name + "\ninfo: {name: x}\n" + fmt.Sprintf(tt.template, cond)}
+			results, handled := s.executeProtocolTemplate(context.Background(), job, info, template)
+			if !handled || len(results) != 1 {
+				t.Fatalf("%s: results = %v, handled = %v", tt.name, results, handled)
+			}
+			return results[0]
+		}
+
+		// 命中和未命中的结果主机一致，对比扫描才能确认发现已修复
+		matched, missed := run(tt.match), run(tt.noMatch)
+		if matched.Matched == "" {
+			t.Errorf("%s: expected a match, got %+v", tt.name, matched)
+		}
+		if !isNoMatch(missed) {
+			t.Errorf("%s: expected no match, got %+v", tt.name, missed)
+		}
+		if matched.Host != info.BaseURL || missed.Host != info.BaseURL {
+			t.Errorf("%s: hosts = %q / %q, want %q", tt.name, matched.Host, missed.Host, info.BaseURL)
+		}
+		if !strings.Contains(matched.Request, tt.addr) {
+			t.Errorf("%s: request %q does not record address %s", tt.name, matched.Request, tt.addr)
+		}
+	}
+}
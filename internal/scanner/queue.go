@@ -0,0 +1,142 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultMaxScans 默认同时运行的扫描数，超出的扫描进入队列等待
+const DefaultMaxScans = 2
+
+// SetMaxScans 设置同时运行的扫描数上限（<=0 使用默认值），调大后立即启动等待中的扫描
+func (s *Scanner) SetMaxScans(n int) {
+	if n <= 0 {
+		n = DefaultMaxScans
+	}
+	s.mu.Lock()
+	s.maxScans = n
+	s.dispatchLocked()
+	s.mu.Unlock()
+}
+
+// enqueueLocked 按优先级将扫描加入队列：优先级高的在前，同优先级先到先执行（调用方持有 s.mu）
+func (s *Scanner) enqueueLocked(job *ScanJob) {
+	job.Status.Status = "queued"
+	pos := len(s.queue)
+	for i, queued := range s.queue {
+		if job.Status.Priority > queued.Status.Priority {
+			pos = i
+			break
+		}
+	}
+	s.queue = append(s.queue, nil)
+	copy(s.queue[pos+1:], s.queue[pos:])
+	s.queue[pos] = job
+	s.updateQueuePositionsLocked()
+}
+
+// removeQueuedLocked 将扫描移出队列，返回扫描是否在队列中（调用方持有 s.mu）
+func (s *Scanner) removeQueuedLocked(job *ScanJob) bool {
+	for i, queued := range s.queue {
+		if queued == job {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			job.Status.QueuePosition = 0
+			s.updateQueuePositionsLocked()
+			return true
+		}
+	}
+	return false
+}
+
+// updateQueuePositionsLocked 刷新队列中扫描的排队位置（从 1 开始）
+func (s *Scanner) updateQueuePositionsLocked() {
+	for i, job := range s.queue {
+		job.Status.QueuePosition = i + 1
+	}
+}
+
+// dispatchLocked 按队列顺序启动等待中的扫描，直到同时运行的扫描数达到上限（调用方持有 s.mu）
+func (s *Scanner) dispatchLocked() {
+	maxScans := s.maxScans
+	if maxScans <= 0 {
+		maxScans = DefaultMaxScans
+	}
+	for len(s.queue) > 0 && s.running < maxScans {
+		job := s.queue[0]
+		s.queue = s.queue[1:]
+		job.Status.Status = "running"
+		job.Status.QueuePosition = 0
+		if job.Status.Completed == 0 {
+			// 未执行过的扫描从真正开始执行时计时
+			job.Status.StartedAt = time.Now()
+		}
+		s.running++
+		go s.runQueuedJob(job)
+	}
+	s.updateQueuePositionsLocked()
+}
+
+// runQueuedJob 执行出队的扫描，结束（完成、暂停或停止）后启动队列中的下一个
+func (s *Scanner) runQueuedJob(job *ScanJob) {
+	defer func() {
+		s.mu.Lock()
+		s.running--
+		s.dispatchLocked()
+		s.mu.Unlock()
+	}()
+	s.runRealScan(job.Context, job)
+}
+
+// queueJobLocked 将新建或继续的扫描加入队列并尝试启动（调用方持有 s.mu）
+func (s *Scanner) queueJobLocked(ctx context.Context, job *ScanJob) {
+	scanCtx, cancel := context.WithCancel(ctx)
+	job.Context = scanCtx
+	job.Cancel = cancel
+	s.enqueueLocked(job)
+	s.dispatchLocked()
+}
+
+// SetScanPriority 修改等待中扫描的优先级，并按新优先级重新排队
+func (s *Scanner) SetScanPriority(scanID string, priority int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.scans[scanID]
+	if !ok {
+		return fmt.Errorf("扫描任务不存在: %s", scanID)
+	}
+	if !s.removeQueuedLocked(job) {
+		return fmt.Errorf("扫描不在队列中: %s", scanID)
+	}
+	job.Status.Priority = priority
+	s.enqueueLocked(job)
+	return nil
+}
+
+// MoveQueuedScan 将等待中的扫描移动到队列中的指定位置（从 1 开始，超出范围时移到队尾）
+// 手动调整的顺序不受优先级约束，优先级只决定之后入队的扫描插入的位置
+func (s *Scanner) MoveQueuedScan(scanID string, position int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.scans[scanID]
+	if !ok {
+		return fmt.Errorf("扫描任务不存在: %s", scanID)
+	}
+	if !s.removeQueuedLocked(job) {
+		return fmt.Errorf("扫描不在队列中: %s", scanID)
+	}
+	pos := position - 1
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > len(s.queue) {
+		pos = len(s.queue)
+	}
+	s.queue = append(s.queue, nil)
+	copy(s.queue[pos+1:], s.queue[pos:])
+	s.queue[pos] = job
+	s.updateQueuePositionsLocked()
+	return nil
+}
@@ -0,0 +1,185 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// queueState 按 ids 顺序返回各扫描的 "任务名:状态:排队位置"
+func queueState(s *Scanner, ids []string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var parts []string
+	for _, id := range ids {
+		st := s.scans[id].Status
+		parts = append(parts, fmt.Sprintf("%s:%s:%d", st.Name, st.Status, st.QueuePosition))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestScanQueue(t *testing.T) {
+	// 每个扫描的请求都阻塞到对应的 release 关闭，arrived 记录各扫描开始发送请求的顺序
+	release := make(map[string]chan struct{})
+	for _, name := range []string{"a", "b", "c", "d"} {
+		release[name] = make(chan struct{})
+	}
+	arrived := make(chan string, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+		arrived <- name
+		<-release[name]
+	}))
+	defer srv.Close()
+	closed := make(map[string]bool)
+	finish := func(name string) {
+		if !closed[name] {
+			close(release[name])
+			closed[name] = true
+		}
+	}
+	defer func() {
+		for name := range release {
+			finish(name)
+		}
+	}()
+
+	s := NewScanner(t.TempDir())
+	s.SetMaxScans(1)
+	opts := models.ScanOptions{AllowPrivate: true, Timeout: 10}
+	start := func(name string, priority int) string {
+		id, err := s.Start(context.Background(), []string{srv.URL + "/" + name + "/"}, "", []models.POCTemplate{wordTemplate("t", "/x", "x")}, nil, "", opts, name, priority)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	next := func() string {
+		select {
+		case name := <-arrived:
+			return name
+		case <-time.After(10 * time.Second):
+			t.Fatal("no scan started")
+			return ""
+		}
+	}
+
+	a := start("a", 0)
+	if got := next(); got != "a" {
+		t.Fatalf("first scan = %s, want a", got)
+	}
+	b := start("b", 0)
+	c := start("c", 0)
+	d := start("d", 5)
+	ids := []string{a, b, c, d}
+
+	steps := []struct {
+		name string
+		do   func() error
+		want string
+	}{
+		{
+			// 高优先级插到前面，同优先级先到先执行
+			name: "priority insertion",
+			do:   func() error { return nil },
+			want: "a:running:0 b:queued:2 c:queued:3 d:queued:1",
+		},
+		{
+			name: "raise priority",
+			do:   func() error { return s.SetScanPriority(c, 10) },
+			want: "a:running:0 b:queued:3 c:queued:1 d:queued:2",
+		},
+		{
+			name: "lower priority",
+			do:   func() error { return s.SetScanPriority(c, 0) },
+			want: "a:running:0 b:queued:2 c:queued:3 d:queued:1",
+		},
+		{
+			name: "move to front",
+			do:   func() error { return s.MoveQueuedScan(c, 1) },
+			want: "a:running:0 b:queued:3 c:queued:1 d:queued:2",
+		},
+		{
+			name: "move past the end",
+			do:   func() error { return s.MoveQueuedScan(c, 99) },
+			want: "a:running:0 b:queued:2 c:queued:3 d:queued:1",
+		},
+		{
+			name: "move to middle",
+			do:   func() error { return s.MoveQueuedScan(d, 2) },
+			want: "a:running:0 b:queued:1 c:queued:3 d:queued:2",
+		},
+		{
+			name: "move before",
+			do:   func() error { return s.MoveQueuedScan(c, 0) },
+			want: "a:running:0 b:queued:2 c:queued:1 d:queued:3",
+		},
+	}
+	for _, step := range steps {
+		if err := step.do(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := queueState(s, ids); got != step.want {
+			t.Errorf("%s: queue = %s, want %s", step.name, got, step.want)
+		}
+	}
+
+	// 运行中和不存在的扫描不能调整
+	if err := s.SetScanPriority(a, 1); err == nil {
+		t.Error("SetScanPriority on a running scan: expected error")
+	}
+	if err := s.MoveQueuedScan(a, 1); err == nil {
+		t.Error("MoveQueuedScan on a running scan: expected error")
+	}
+	if err := s.MoveQueuedScan("missing", 1); err == nil {
+		t.Error("MoveQueuedScan on an unknown scan: expected error")
+	}
+
+	// 调大上限立即启动队首的扫描
+	s.SetMaxScans(2)
+	if got := next(); got != "c" {
+		t.Errorf("started after raising the limit = %s, want c", got)
+	}
+	if got, want := queueState(s, ids), "a:running:0 b:queued:1 c:running:0 d:queued:2"; got != want {
+		t.Errorf("after raising the limit: queue = %s, want %s", got, want)
+	}
+
+	// 运行中的扫描结束后按队列顺序启动剩余扫描
+	finish("a")
+	if got := next(); got != "b" {
+		t.Errorf("started after a finished = %s, want b", got)
+	}
+	if got, want := queueState(s, ids), "a:completed:0 b:running:0 c:running:0 d:queued:1"; got != want {
+		t.Errorf("after a finished: queue = %s, want %s", got, want)
+	}
+	finish("c")
+	if got := next(); got != "d" {
+		t.Errorf("started after c finished = %s, want d", got)
+	}
+	finish("b")
+	finish("d")
+	for _, id := range ids {
+		if st := waitScan(t, s, id); st.Status != "completed" || st.QueuePosition != 0 {
+			t.Errorf("%s: status = %s, position = %d", st.Name, st.Status, st.QueuePosition)
+		}
+	}
+
+	// 等待扫描协程退出，保存结果后再清理临时目录
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		s.mu.RLock()
+		running := s.running
+		s.mu.RUnlock()
+		if running == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d scans still running", running)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sendRawRequest 发送 raw 请求：先展开变量，再按 unsafe 决定发送方式
+//   - 普通模式：解析为 method/path/headers/body，经 net/http 发送（Host 头保留）
+//   - unsafe 模式：原始字节直接写入 TCP/TLS 连接，保留重复 header、畸形行等
+func (s *Scanner) sendRawRequest(ctx context.Context, client *http.Client, target *targetInfo, reqConfig HTTPRequest, raw string, vars map[string]interface{}, respLimit int64) (*httpExchange, error) {
+	expanded, err := expandVariables(raw, vars)
+	if err != nil {
+		return nil, err
+	}
+	if reqConfig.Unsafe {
+		return sendUnsafeRequest(ctx, client, target, expanded, respLimit, reqConfig.ReadAll)
+	}
+
+	method, path, headers, body := parseRawRequest(expanded)
+	return s.doHTTPRequest(ctx, client, method, target.resolveURL(path), headers, body, respLimit)
+}
+
+// unsafeRequestBytes 生成 unsafe 模式实际写入连接的字节
+// 模板中没有显式 \r\n 时把换行转换为 CRLF；缺少头部结束空行时补齐，其余内容不做任何修改
+func unsafeRequestBytes(raw string) []byte {
+	raw = strings.TrimLeft(raw, "\r\n\t ")
+	if !strings.Contains(raw, "\r\n") {
+		raw = strings.ReplaceAll(raw, "\n", "\r\n")
+	}
+	if !strings.Contains(raw, "\r\n\r\n") {
+		raw = strings.TrimRight(raw, "\r\n") + "\r\n\r\n"
+	}
+	return []byte(raw)
+}
+
+// sendUnsafeRequest 将原始请求写入到目标的 TCP/TLS 连接并读取原始响应
+// 不经过代理和 net/http，响应能按 HTTP 解析时照常生成 status_code / header / body，
+// 否则整个原始响应作为 body 供 matcher 使用
+// readAll 为 true 时一直读取到连接关闭（或超时），头部之后的全部数据都作为 body（用于请求走私等场景）
+func sendUnsafeRequest(ctx context.Context, client *http.Client, target *targetInfo, raw string, respLimit int64, readAll bool) (*httpExchange, error) {
+	data := unsafeRequestBytes(raw)
+
+	timeout := client.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(target.Host, target.Port))
+	if err != nil {
+		return nil, fmt.Errorf("连接失败: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("设置超时失败: %v", err)
+	}
+
+	// 扫描被取消时立即关闭连接
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if target.Scheme == "https" {
+		tlsConn := tls.Client(conn, unsafeTLSConfig(client, target.Host))
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("TLS 握手失败: %v", err)
+		}
+		conn = tlsConn
+	}
+
+	start := time.Now()
+	if _, err := conn.Write(data); err != nil {
+		return nil, fmt.Errorf("请求失败: %v", err)
+	}
+
+	// 读取响应的同时保留原始字节（头部额外预留 64KB）
+	var captured bytes.Buffer
+	limited := io.LimitReader(conn, respLimit+64*1024)
+	src := io.TeeReader(limited, &captured)
+	if readAll {
+		// 先读到连接关闭（超时也视为读取结束），再从缓冲中解析
+		io.Copy(&captured, limited)
+		src = bytes.NewReader(captured.Bytes())
+	}
+	reader := bufio.NewReader(src)
+	method, _, _ := strings.Cut(string(data), " ")
+	resp, err := http.ReadResponse(reader, &http.Request{Method: strings.ToUpper(method)})
+
+	var event map[string]interface{}
+	if err == nil {
+		var body []byte
+		if readAll {
+			// 忽略 Content-Length / chunked，头部之后的所有数据都是 body
+			body, _ = io.ReadAll(io.LimitReader(reader, respLimit))
+		} else {
+			body, _ = io.ReadAll(io.LimitReader(resp.Body, respLimit))
+		}
+		resp.Body.Close()
+		event = buildHTTPEvent(resp, body)
+	} else {
+		// 非标准 HTTP 响应：读完剩余数据，整体作为 body
+		io.Copy(io.Discard, reader)
+		if captured.Len() == 0 {
+			return nil, fmt.Errorf("读取响应失败: %v", err)
+		}
+		rawResp := captured.String()
+		event = map[string]interface{}{
+			"body":           rawResp,
+			"header":         "",
+			"all":            rawResp,
+			"status_code":    0,
+			"content_length": len(rawResp),
+		}
+	}
+	event["raw"] = captured.String()
+	event["duration"] = time.Since(start).Seconds()
+
+	response := captured.String()
+	if len(response) > 4096 {
+		response = response[:4096] + "\n... (truncated)"
+	}
+	return &httpExchange{
+		event:    event,
+		request:  string(data),
+		response: response,
+	}, nil
+}
+
+// unsafeTLSConfig 复用 HTTP 客户端的 TLS 配置（未配置时使用默认配置）
+func unsafeTLSConfig(client *http.Client, serverName string) *tls.Config {
+	cfg := &tls.Config{}
+	if t, ok := client.Transport.(*http.Transport); ok && t.TLSClientConfig != nil {
+		cfg = t.TLSClientConfig.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = serverName
+	}
+	return cfg
+}
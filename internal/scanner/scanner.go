@@ -3,7 +3,9 @@ package scanner
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"math/rand"
@@ -13,11 +15,13 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"nuclei-poc-manager/internal/models"
+	"nuclei-poc-manager/internal/oast"
 
 	"gopkg.in/yaml.v3"
 )
@@ -32,10 +36,15 @@ const (
 	DefaultRespTruncate  = 2048        // 响应展示截断长度
 	DefaultRateLimit     = 100         // 默认每秒最大请求
 	DefaultRetryCount    = 0           // 默认不重试
+	CheckpointInterval   = 10          // 扫描断点保存间隔（秒）
 	MinTargetLen         = 1           // 最短目标长度
 	MaxMetadataLines     = 300         // 模板元数据最大读取行数
 )
 
+// msgNoMatch 模板在目标上收到了响应但没有任何命中时的错误信息，不保存到扫描结果中
+// 请求失败时结果的错误信息为失败原因，与未命中区分
+const msgNoMatch = "所有请求均未匹配"
+
 // allowedSchemes 允许的目标 scheme
 var allowedSchemes = map[string]bool{
 	"http":  true,
@@ -66,28 +75,82 @@ func init() {
 }
 
 // Scanner 扫描器
+
 type Scanner struct {
 	scans    map[string]*ScanJob
 	results  map[string][]models.ScanResult
-	scansDir string // 扫描结果持久化目录
+	checked  map[string]map[string]bool // 扫描 ID -> 收到响应但未命中的已有发现（模板 + 目标，见 templateHostKey）
+	scansDir string       // 扫描结果持久化目录
+	oast     *oast.Server // 内置带外交互服务（未启用时为 nil）
 	mu       sync.RWMutex
+	diskMu   sync.Mutex // 串行化结果日志和状态快照的写入
+
+	queue    []*ScanJob // 等待执行的扫描（按执行顺序）
+	running  int        // 正在执行的扫描数
+	maxScans int        // 同时运行的扫描数上限
 }
 
 // ScanJob 扫描任务
 type ScanJob struct {
 	ID           string
 	Status       *models.ScanStatus
+	Context      context.Context // 扫描上下文（排队期间保存，出队执行时使用）
 	Cancel       context.CancelFunc
-	Templates    []models.POCTemplate
+	Templates    []models.POCTemplate // 未参与聚类的模板
+	Clusters     []templateCluster    // 请求相同、共享一次请求的模板组
+	Workflows    []models.Workflow
 	Targets      []string
+	TargetsFile  string // 目标列表文件（扫描时逐行读取）
 	TemplatesDir string
 	Options      models.ScanOptions
+	// RequestCounts 模板 / 工作流 ID -> 单个目标上的实际请求数（用于进度统计）
+	RequestCounts map[string]int
+	// Checkpoint 已完成的任务（暂停 / 中断后从这里继续），扫描完成后清除
+	Checkpoint *scanCheckpoint
+	limiter    *rateLimiter    // 请求速率限制（扫描运行期间有效，nil 表示不限速）
+	transport  *http.Transport // HTTP 传输层（代理、TLS 配置），WebSocket 等按此连接，扫描运行期间有效
+	payloads   *payloadCache   // 已加载的 payload 组合（扫描运行期间有效，所有目标共用）
+}
+
+// rateLimiter 扫描的请求速率限制：每发送一个请求（HTTP 请求、DNS 查询、TCP 连接、TLS 握手）等待一次
+// 一个模板可能发送多个 path、payload 组合和 raw 请求，因此按请求而不是按模板限速
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+// newRateLimiter 创建每秒 rate 个请求的限速器（> 500 req/s 则跳过限制，避免过高 CPU 开销）
+func newRateLimiter(rate int) *rateLimiter {
+	if rate <= 0 || rate > 500 {
+		return nil
+	}
+	return &rateLimiter{ticker: time.NewTicker(time.Second / time.Duration(rate))}
+}
+
+// wait 等待发送下一个请求（nil 时立即返回）
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case <-l.ticker.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stop 停止限速器
+func (l *rateLimiter) stop() {
+	if l != nil {
+		l.ticker.Stop()
+	}
 }
 
-// savedScan 持久化的扫描数据
+// savedScan 持久化的扫描状态快照，结果单独追加写入结果日志
 type savedScan struct {
-	Status  models.ScanStatus   `json:"status"`
-	Results []models.ScanResult `json:"results"`
+	Status     models.ScanStatus   `json:"status"`
+	Results    []models.ScanResult `json:"results,omitempty"` // 旧版本快照中的结果，加载时迁移到结果日志
+	Checkpoint *scanCheckpoint     `json:"checkpoint,omitempty"`
 }
 
 // NewScanner 创建新的扫描器
@@ -95,6 +158,7 @@ func NewScanner(scansDir string) *Scanner {
 	s := &Scanner{
 		scans:    make(map[string]*ScanJob),
 		results:  make(map[string][]models.ScanResult),
+		checked:  make(map[string]map[string]bool),
 		scansDir: scansDir,
 	}
 	// 从磁盘加载历史扫描
@@ -102,7 +166,7 @@ func NewScanner(scansDir string) *Scanner {
 	return s
 }
 
-// loadScansFromDisk 从磁盘加载历史扫描任务
+// loadScansFromDisk 从磁盘加载历史扫描任务：读取状态快照，再用结果日志重建结果和进度
 func (s *Scanner) loadScansFromDisk() {
 	if s.scansDir == "" {
 		return
@@ -114,13 +178,20 @@ func (s *Scanner) loadScansFromDisk() {
 		return
 	}
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+		if entry.IsDir() {
+			continue
+		}
+		// 清理写入中途退出留下的临时文件
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			os.Remove(filepath.Join(s.scansDir, entry.Name()))
+			continue
+		}
+		if !strings.HasSuffix(entry.Name(), ".json") {
 			continue
 		}
 		scanID := strings.TrimSuffix(entry.Name(), ".json")
-		filePath := filepath.Join(s.scansDir, entry.Name())
 
-		data, err := os.ReadFile(filePath)
+		data, err := os.ReadFile(s.scanFilePath(scanID))
 		if err != nil {
 			continue
 		}
@@ -130,54 +201,119 @@ func (s *Scanner) loadScansFromDisk() {
 			continue
 		}
 
-		// 恢复结果
-		s.results[scanID] = saved.Results
+		// 旧版本快照中的结果迁移到结果日志
+		logPath := s.resultLogPath(scanID)
+		if len(saved.Results) > 0 {
+			if _, err := os.Stat(logPath); os.IsNotExist(err) {
+				line, err := json.Marshal(resultLogEntry{Target: -1, Results: saved.Results})
+				if err != nil || writeFileAtomic(logPath, append(line, '\n'), 0644) != nil {
+					continue
+				}
+			}
+		}
+		logEntries, _ := readResultLog(logPath)
+
+		// 按结果日志重建结果；快照之后才完成的任务补记到断点和进度中
+		status := &saved.Status
+		results := []models.ScanResult{}
+		status.Found = 0
+		status.Errors = 0
+		for _, e := range logEntries {
+			for _, c := range e.Checked {
+				s.markCheckedLocked(scanID, c)
+			}
+			failed := 0
+			for _, r := range e.Results {
+				results = append(results, r)
+				if r.Matched != "" {
+					status.Found++
+				} else if r.Error != "" {
+					failed++
+				}
+			}
+			// 旧版本的结果日志没有 failed 字段，每条错误结果都保存了
+			status.Errors += max(failed, e.Failed)
+			if saved.Checkpoint != nil && e.Unit != "" && !saved.Checkpoint.isDone(e.Target, e.Unit) {
+				saved.Checkpoint.markDone(e.Target, e.Unit, 0)
+				status.Completed += e.Requests
+			}
+		}
+		if status.Status != "completed" && status.Total > 0 {
+			status.Progress = float64(status.Completed) / float64(status.Total) * 100
+		}
+		s.results[scanID] = results
+
+		// 上次运行时应用崩溃或被强制结束的扫描（正常退出时会标记为暂停）
+		switch status.Status {
+		case "running":
+			status.Status = "paused"
+			status.Error = "扫描意外中断"
+		case "queued":
+			status.Status = "paused"
+		}
+		status.QueuePosition = 0
+		status.Resumable = saved.Checkpoint != nil && status.Status != "completed"
 
 		// 恢复状态（无 Cancel 函数，标记为历史任务）
 		s.scans[scanID] = &ScanJob{
 			ID:           scanID,
-			Status:       &saved.Status,
+			Status:       status,
 			Cancel:       nil,
 			TemplatesDir: s.scansDir,
+			Checkpoint:   saved.Checkpoint,
 		}
 	}
 }
 
-// saveScanToDisk 将扫描结果保存到磁盘
+// saveScanToDisk 保存扫描状态快照（结果已实时写入结果日志）
 func (s *Scanner) saveScanToDisk(scanID string) {
 	if s.scansDir == "" {
 		return
 	}
 	os.MkdirAll(s.scansDir, 0755)
 
+	// 串行写入，保证后保存的快照不会被先构建的旧快照覆盖
+	s.diskMu.Lock()
+	defer s.diskMu.Unlock()
+
+	s.mu.RLock()
 	job, ok := s.scans[scanID]
 	if !ok {
+		s.mu.RUnlock()
 		return
 	}
-	results := s.results[scanID]
-
 	saved := savedScan{
-		Status:  *job.Status,
-		Results: results,
+		Status:     *job.Status,
+		Checkpoint: job.Checkpoint,
 	}
-
 	data, err := json.MarshalIndent(saved, "", "  ")
+	s.mu.RUnlock()
 	if err != nil {
 		return
 	}
 
-	filePath := filepath.Join(s.scansDir, scanID+".json")
-	os.WriteFile(filePath, data, 0644)
+	writeFileAtomic(s.scanFilePath(scanID), data, 0644)
 }
 
 // Start 开始扫描
-func (s *Scanner) Start(ctx context.Context, targets []string, templates []models.POCTemplate, templatesDir string, opts models.ScanOptions, taskName string) (string, error) {
+// targetsFile 为目标列表文件（每行一个目标），扫描时逐行读取，与 targets 合并
+// workflows 中的每个工作流作为一个整体在每个目标上执行
+// 扫描先按 priority 进入队列，同时运行的扫描数未达上限时立即开始
+// 指定 taskName 时以它作为扫描 ID，已存在同名扫描（内存或磁盘上）时返回错误
+func (s *Scanner) Start(ctx context.Context, targets []string, targetsFile string, templates []models.POCTemplate, workflows []models.Workflow, templatesDir string, opts models.ScanOptions, taskName string, priority int) (string, error) {
 	scanID := fmt.Sprintf("scan_%d", time.Now().UnixNano())
 	if taskName != "" {
+		if strings.ContainsAny(taskName, `/\`) || taskName == "." || taskName == ".." {
+			return "", fmt.Errorf("无效的任务名称: %s", taskName)
+		}
 		scanID = taskName
 	}
 
-	scanCtx, cancel := context.WithCancel(ctx)
+	// 只统计数量，目标在扫描过程中流式读取
+	targetCount, err := countTargets(targets, targetsFile)
+	if err != nil {
+		return "", err
+	}
 
 	// 设置默认值
 	if opts.Concurrency <= 0 {
@@ -187,42 +323,103 @@ func (s *Scanner) Start(ctx context.Context, targets []string, templates []model
 		opts.Timeout = 30
 	}
 
+	job, perTarget, saved := newScanJob(scanID, targets, targetsFile, templates, workflows, templatesDir, opts)
 	status := &models.ScanStatus{
-		ID:          scanID,
-		Status:      "running",
-		Name:        taskName,
-		Total:       len(targets) * len(templates),
-		Completed:   0,
-		Found:       0,
-		StartedAt:   time.Now(),
-		Targets:     targets,
-		TemplateIDs: make([]string, len(templates)),
+		ID:            scanID,
+		Status:        "queued",
+		Name:          taskName,
+		Total:         targetCount * perTarget,
+		Completed:     0,
+		Found:         0,
+		StartedAt:     time.Now(),
+		Targets:       targets,
+		TargetsFile:   targetsFile,
+		TargetCount:   targetCount,
+		TemplateIDs:   make([]string, len(templates)),
+		RequestsSaved: targetCount * saved,
+		Priority:      priority,
 	}
 
 	for i, t := range templates {
 		status.TemplateIDs[i] = t.ID
 	}
-
-	job := &ScanJob{
-		ID:           scanID,
-		Status:       status,
-		Cancel:       cancel,
-		Templates:    templates,
-		Targets:      targets,
-		TemplatesDir: templatesDir,
-		Options:      opts,
+	for _, wf := range workflows {
+		status.WorkflowIDs = append(status.WorkflowIDs, wf.ID)
 	}
+	job.Status = status
+	job.Checkpoint = &scanCheckpoint{Options: opts}
 
 	s.mu.Lock()
+	if s.scanExistsLocked(scanID) {
+		s.mu.Unlock()
+		return "", fmt.Errorf("扫描任务已存在: %s", scanID)
+	}
 	s.scans[scanID] = job
 	s.results[scanID] = []models.ScanResult{}
+	s.queueJobLocked(ctx, job)
 	s.mu.Unlock()
 
-	go s.runRealScan(scanCtx, job)
-
+	s.saveScanToDisk(scanID)
 	return scanID, nil
 }
 
+// scanExistsLocked 判断扫描 ID 是否已被使用：避免新扫描追加到旧扫描的结果日志中（调用方持有 s.mu）
+func (s *Scanner) scanExistsLocked(scanID string) bool {
+	if _, ok := s.scans[scanID]; ok {
+		return true
+	}
+	if s.scansDir == "" {
+		return false
+	}
+	for _, path := range []string{s.scanFilePath(scanID), s.resultLogPath(scanID)} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// newScanJob 构建扫描任务（不含状态）：请求相同的模板聚类，并统计单个目标上的请求数
+// 返回单个目标的请求数和聚类节省的请求数
+func newScanJob(scanID string, targets []string, targetsFile string, templates []models.POCTemplate, workflows []models.Workflow, templatesDir string, opts models.ScanOptions) (*ScanJob, int, int) {
+	// 请求完全相同的模板聚类，每个目标只发送一次
+	clusters, unclustered := clusterTemplates(templates)
+	saved := 0
+	for _, c := range clusters {
+		saved += len(c.Templates) - 1
+	}
+
+	// 按实际请求数统计总量（一个模板可能包含多个 path）
+	requestCounts := make(map[string]int, len(templates))
+	perTarget := len(clusters)
+	for _, c := range clusters {
+		requestCounts[c.ID] = 1
+	}
+	for _, t := range unclustered {
+		n := countTemplateRequests(t, templatesDir)
+		requestCounts[t.ID] = n
+		perTarget += n
+	}
+	for _, wf := range workflows {
+		n := workflowRequestCount(wf, templatesDir)
+		requestCounts[wf.ID] = n
+		perTarget += n
+	}
+
+	job := &ScanJob{
+		ID:            scanID,
+		Templates:     unclustered,
+		Clusters:      clusters,
+		Workflows:     workflows,
+		Targets:       targets,
+		TargetsFile:   targetsFile,
+		TemplatesDir:  templatesDir,
+		Options:       opts,
+		RequestCounts: requestCounts,
+	}
+	return job, perTarget, saved
+}
+
 // runRealScan 执行真实的 HTTP 扫描（支持并发、代理、速率限制）
 func (s *Scanner) runRealScan(ctx context.Context, job *ScanJob) {
 	defer func() {
@@ -230,7 +427,7 @@ func (s *Scanner) runRealScan(ctx context.Context, job *ScanJob) {
 			s.mu.Lock()
 			job.Status.Status = "failed"
 			job.Status.Error = fmt.Sprintf("扫描崩溃: %v", r)
-			job.Status.Progress = 100
+			job.Status.Resumable = job.Checkpoint != nil
 			job.Status.CompletedAt = time.Now()
 			s.mu.Unlock()
 			// 崩溃也保存已收集的结果
@@ -238,6 +435,9 @@ func (s *Scanner) runRealScan(ctx context.Context, job *ScanJob) {
 		}
 	}()
 
+	// 开始前先保存一次快照，首次定期保存之前崩溃也能从结果日志恢复
+	s.saveScanToDisk(job.ID)
+
 	concurrency := job.Options.Concurrency
 	if concurrency <= 0 {
 		concurrency = DefaultConcurrency
@@ -252,63 +452,134 @@ func (s *Scanner) runRealScan(ctx context.Context, job *ScanJob) {
 	}
 
 	// 创建 HTTP 传输层（支持代理）
-	transport := &http.Transport{
+	// 默认每个请求使用新连接（Connection: close），启用 KeepAlive 后复用连接
+	reuse := &http.Transport{
 		MaxIdleConns:        concurrency * 2,
-		MaxConnsPerHost:     10,
+		MaxIdleConnsPerHost: concurrency,
+		MaxConnsPerHost:     concurrency,
 		IdleConnTimeout:     90 * time.Second,
 		DisableCompression:  false,
-		DisableKeepAlives:   false,
 	}
 
 	// 代理设置
 	if job.Options.ProxyURL != "" {
 		proxyURL, err := url.Parse(job.Options.ProxyURL)
 		if err == nil {
-			transport.Proxy = http.ProxyURL(proxyURL)
+			reuse.Proxy = http.ProxyURL(proxyURL)
 		}
 	}
+	noReuse := reuse.Clone()
+	noReuse.DisableKeepAlives = true
+	job.transport = reuse
 
-	// 创建 HTTP 客户端
+	// 创建 HTTP 客户端（重定向策略和连接复用由每个请求块单独决定，见 requestClient）
 	client := &http.Client{
 		Timeout:   timeout,
-		Transport: transport,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= DefaultMaxRedirects {
-				return fmt.Errorf("too many redirects")
-			}
-			return nil
-		},
+		Transport: &keepAliveTransport{reuse: reuse, noReuse: noReuse, keepAlive: job.Options.KeepAlive},
 	}
 
-	// 速率限制器（> 500 req/s 则跳过限制，避免过高 CPU 开销）
-	var rateLimiter *time.Ticker
-	if rateLimit > 0 && rateLimit <= 500 {
-		rateLimiter = time.NewTicker(time.Second / time.Duration(rateLimit))
-		defer rateLimiter.Stop()
-	}
+	// 速率限制器，在每个请求发送前等待（见 sendRequest 和各协议的执行函数）
+	job.limiter = newRateLimiter(rateLimit)
+	defer job.limiter.stop()
+	job.payloads = newPayloadCache()
 
 	// 构建所有扫描任务
 	type scanTask struct {
-		target   string
-		template models.POCTemplate
+		target   *scanTarget                 // 同一目标的任务共享（每个目标只解析一次）
+		template models.POCTemplate // 工作流 / 聚类任务中只有 ID / Name，用于进度统计
+		workflow *models.Workflow
+		cluster  *templateCluster
+	}
+	// taskOutcome 单个任务的执行结果（requests 为该任务计入进度的请求数）
+	type taskOutcome struct {
+		results  []*models.ScanResult
+		requests int
+		target   int    // 目标序号
+		unit     string // 模板 / 聚类 / 工作流 ID（记录到断点）
+	}
+	// taskErrors 任务无法执行时的错误结果（聚类任务为其中每个模板各生成一条）
+	taskErrors := func(task scanTask, msg string) []*models.ScanResult {
+		if task.cluster == nil {
+			return []*models.ScanResult{newErrorResult(task.template, task.target.raw, msg)}
+		}
+		results := make([]*models.ScanResult, 0, len(task.cluster.Templates))
+		for _, t := range task.cluster.Templates {
+			results = append(results, newErrorResult(t, task.target.raw, msg))
+		}
+		return results
+	}
+	// tasksPerTarget 每个目标上的全部任务（模板、聚类、工作流）
+	var tasksPerTarget []scanTask
+	for _, template := range job.Templates {
+		tasksPerTarget = append(tasksPerTarget, scanTask{template: template})
 	}
-	tasks := make([]scanTask, 0, len(job.Targets)*len(job.Templates))
-	for _, target := range job.Targets {
-		for _, template := range job.Templates {
-			tasks = append(tasks, scanTask{target, template})
+	for i := range job.Clusters {
+		c := &job.Clusters[i]
+		tasksPerTarget = append(tasksPerTarget, scanTask{template: models.POCTemplate{ID: c.ID}, cluster: c})
+	}
+	for i := range job.Workflows {
+		wf := &job.Workflows[i]
+		tasksPerTarget = append(tasksPerTarget, scanTask{template: models.POCTemplate{ID: wf.ID, Name: wf.Name}, workflow: wf})
+	}
+	units := make(map[string]bool, len(tasksPerTarget))
+	for _, task := range tasksPerTarget {
+		units[task.template.ID] = true
+	}
+
+	// 断点续扫：跳过断点中已完成的任务
+	s.mu.Lock()
+	if job.Checkpoint == nil {
+		job.Checkpoint = &scanCheckpoint{Options: job.Options}
+	}
+	resume := job.Checkpoint.clone()
+	s.mu.Unlock()
+	targetTasks := func(target *scanTarget, emit func(scanTask) bool) bool {
+		for _, task := range tasksPerTarget {
+			if resume.isDone(target.index, task.template.ID) {
+				continue
+			}
+			task.target = target
+			if !emit(task) {
+				return false
+			}
 		}
+		return true
 	}
 
-	total := len(tasks)
-	completed := 0
+	// withRetry 执行一次扫描单元（失败重试）
+	maxRetries := job.Options.RetryCount
+	if maxRetries < 0 {
+		maxRetries = DefaultRetryCount
+	}
+	withRetry := func(execute func() []*models.ScanResult) []*models.ScanResult {
+		var results []*models.ScanResult
+		for attempt := 0; attempt <= maxRetries; attempt++ {
+			if ctx.Err() != nil {
+				return results
+			}
+			results = execute()
+			if !hasOnlyErrors(results) {
+				break
+			}
+			if attempt < maxRetries {
+				time.Sleep(time.Duration(attempt+1) * 500 * time.Millisecond)
+			}
+		}
+		return results
+	}
+	runTemplate := func(info *targetInfo, template models.POCTemplate) []*models.ScanResult {
+		return withRetry(func() []*models.ScanResult {
+			return s.executeTemplate(ctx, client, job, info, template)
+		})
+	}
 
-	// 使用带缓冲但不过大的 channel（concurrency * 16 避免大内存）
+	total := job.Status.Total
+	completed := job.Status.Completed // 断点续扫时从上次的进度继续
+
+	// 任务按目标逐个生成，channel 容量固定（concurrency * 16），内存占用与目标数、模板数无关
 	chBuf := concurrency * 16
-	if chBuf > len(tasks) {
-		chBuf = len(tasks)
-	}
 	taskCh := make(chan scanTask, chBuf)
-	resultCh := make(chan *models.ScanResult, chBuf)
+	resultCh := make(chan taskOutcome, chBuf)
 
 	// 启动 worker goroutines
 	var wg sync.WaitGroup
@@ -324,44 +595,43 @@ func (s *Scanner) runRealScan(ctx context.Context, job *ScanJob) {
 					if !ok {
 						return
 					}
-					// 速率限制（nil 表示无限制）
-					if rateLimiter != nil {
-						<-rateLimiter.C
+					weight := job.RequestCounts[task.template.ID]
+					if weight <= 0 {
+						weight = 1
 					}
 
-					// 允许私有地址检查
-					if !job.Options.AllowPrivate {
-						if err := validateTarget(task.target); err != nil {
-							resultCh <- &models.ScanResult{
-								ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
-								TemplateID:   task.template.ID,
-								TemplateName: task.template.Name,
-								Severity:     task.template.Severity,
-								Host:         task.target,
-								Error:        fmt.Sprintf("目标被拒绝: %v", err),
-								Timestamp:    time.Now(),
-							}
-							continue
+					// 目标解析失败或被私有地址检查拒绝
+					if err := task.target.check(job.Options.AllowPrivate); err != nil {
+						resultCh <- taskOutcome{
+							results:  taskErrors(task, err.Error()),
+							requests: weight,
+							target:   task.target.index,
+							unit:     task.template.ID,
 						}
+						continue
 					}
-
-					// 执行扫描（支持重试）
-					var result *models.ScanResult
-					maxRetries := job.Options.RetryCount
-					if maxRetries < 0 {
-						maxRetries = DefaultRetryCount
+					info := task.target.info
+
+					// 执行扫描（支持重试）；工作流在同一目标上按条件依次执行模板
+					var results []*models.ScanResult
+					switch {
+					case task.cluster != nil:
+						results = withRetry(func() []*models.ScanResult {
+							return s.executeCluster(ctx, client, job, info, *task.cluster)
+						})
+					case task.workflow != nil:
+						results = runWorkflowSteps(ctx, task.workflow.Steps, func(t models.POCTemplate) []*models.ScanResult {
+							return runTemplate(info, t)
+						})
+					default:
+						results = runTemplate(info, task.template)
 					}
-					for attempt := 0; attempt <= maxRetries; attempt++ {
-						result = s.executeTemplate(ctx, client, task.target, task.template, job.Options.MaxResponseSize)
-						if result == nil || result.Error == "" {
-							break
-						}
-						if attempt < maxRetries {
-							time.Sleep(time.Duration(attempt+1) * 500 * time.Millisecond)
-						}
+					// 暂停 / 停止时被中断的任务结果不完整，不记入断点，继续扫描时重新执行
+					if ctx.Err() != nil {
+						return
 					}
 					select {
-					case resultCh <- result:
+					case resultCh <- taskOutcome{results: results, requests: weight, target: task.target.index, unit: task.template.ID}:
 					case <-ctx.Done():
 						return
 					}
@@ -370,17 +640,30 @@ func (s *Scanner) runRealScan(ctx context.Context, job *ScanJob) {
 		}()
 	}
 
-	// 发送任务到通道
+	// 按目标流式生成任务（目标文件逐行读取）
 	go func() {
-		for _, task := range tasks {
+		defer close(taskCh)
+		emit := func(task scanTask) bool {
 			select {
 			case taskCh <- task:
+				return true
 			case <-ctx.Done():
-				close(taskCh)
-				return
+				return false
+			}
+		}
+		index := -1
+		err := eachTarget(job.Targets, job.TargetsFile, func(raw string) bool {
+			index++
+			if index < resume.DoneTargets {
+				return true
 			}
+			return targetTasks(newScanTarget(index, raw), emit)
+		})
+		if err != nil {
+			s.mu.Lock()
+			job.Status.Error = err.Error()
+			s.mu.Unlock()
 		}
-		close(taskCh)
 	}()
 
 	// 等待所有 workers 完成，然后关闭结果通道
@@ -389,34 +672,93 @@ func (s *Scanner) runRealScan(ctx context.Context, job *ScanJob) {
 		close(resultCh)
 	}()
 
-	// 收集结果（仅保存成功匹配 + 错误，非匹配跳过以节省空间）
-	for result := range resultCh {
-		completed++
-		if result != nil && (result.Matched != "" || result.Error != "") {
-			result.ScanID = job.ID
-			s.mu.Lock()
-			s.results[job.ID] = append(s.results[job.ID], *result)
-			if result.Matched != "" {
-				job.Status.Found++
+	// 收集结果（保存成功匹配和请求失败等错误，收到响应但未命中的跳过以节省空间），并定期保存断点
+	// 无法访问的目标上每个模板都会失败，每个目标只保存第一条错误结果，其余只计数
+	// 其他扫描中已有的发现在本次扫描中未命中时单独记录，对比扫描时据此确认已修复
+	s.mu.RLock()
+	findings := s.findingPairsLocked(job.ID)
+	s.mu.RUnlock()
+	errorTargets := make(map[int]bool) // 已保存错误结果的目标（未完成的目标）
+	lastSave := time.Now()
+	for outcome := range resultCh {
+		completed += outcome.requests
+		var kept []models.ScanResult
+		var checked []checkedTemplate
+		failed := 0
+		for _, result := range outcome.results {
+			if result == nil {
+				continue
 			}
-			s.mu.Unlock()
+			if isNoMatch(result) {
+				if findings[templateHostKey(result.TemplateID, result.Host)] {
+					checked = append(checked, checkedTemplate{TemplateID: result.TemplateID, Host: result.Host})
+				}
+				continue
+			}
+			if result.Matched == "" && result.Error != "" {
+				failed++
+				if errorTargets[outcome.target] {
+					continue
+				}
+				errorTargets[outcome.target] = true
+			}
+			if result.Matched != "" || result.Error != "" {
+				result.ScanID = job.ID
+				kept = append(kept, *result)
+			}
+		}
+		// 结果先写入结果日志，应用被强制结束也不会丢失
+		if len(kept) > 0 || len(checked) > 0 || failed > 0 {
+			s.appendResultLog(job.ID, resultLogEntry{Target: outcome.target, Unit: outcome.unit, Requests: outcome.requests, Results: kept, Checked: checked, Failed: failed})
 		}
 
 		s.mu.Lock()
+		job.Checkpoint.markDone(outcome.target, outcome.unit, len(units))
+		for target := range errorTargets {
+			if target < job.Checkpoint.DoneTargets {
+				delete(errorTargets, target)
+			}
+		}
+		for _, c := range checked {
+			s.markCheckedLocked(job.ID, c)
+		}
+		for _, result := range kept {
+			s.results[job.ID] = append(s.results[job.ID], result)
+			if result.Matched != "" {
+				job.Status.Found++
+			}
+		}
+		job.Status.Errors += failed
 		job.Status.Completed = completed
-		job.Status.Progress = float64(completed) / float64(total) * 100
+		if total > 0 {
+			job.Status.Progress = float64(completed) / float64(total) * 100
+		}
 		s.mu.Unlock()
+
+		if time.Since(lastSave) >= CheckpointInterval*time.Second {
+			s.saveScanToDisk(job.ID)
+			lastSave = time.Now()
+		}
 	}
 
-	// 判断最终状态
+	// 判断最终状态（暂停 / 停止的扫描保留断点，可以继续）
 	s.mu.Lock()
 	select {
 	case <-ctx.Done():
-		job.Status.Status = "stopped"
+		if job.Status.Status != "paused" {
+			job.Status.Status = "stopped"
+		}
+		job.Status.Resumable = true
 	default:
 		job.Status.Status = "completed"
+		job.Status.Progress = 100
+		job.Checkpoint = nil
+		if job.Status.Error != "" {
+			// 目标文件读取中断
+			job.Status.Status = "failed"
+			job.Status.Resumable = true
+		}
 	}
-	job.Status.Progress = 100
 	job.Status.CompletedAt = time.Now()
 	s.mu.Unlock()
 
@@ -425,34 +767,26 @@ func (s *Scanner) runRealScan(ctx context.Context, job *ScanJob) {
 }
 
 // executeTemplate 执行单个模板扫描（支持 extractors、变量展开、错误记录）
-func (s *Scanner) executeTemplate(ctx context.Context, client *http.Client, target string, template models.POCTemplate, maxResponseSize int) *models.ScanResult {
+// 返回该模板在目标上的所有命中结果；无命中时返回单个错误结果
+func (s *Scanner) executeTemplate(ctx context.Context, client *http.Client, job *ScanJob, info *targetInfo, template models.POCTemplate) []*models.ScanResult {
+	target := info.BaseURL
+
 	// 解析模板内容
 	if template.Content == "" && template.FilePath != "" {
 		content, err := os.ReadFile(template.FilePath)
 		if err != nil {
-			return &models.ScanResult{
-				ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
-				TemplateID:   template.ID,
-				TemplateName: template.Name,
-				Severity:     template.Severity,
-				Host:         target,
-				Error:        fmt.Sprintf("读取模板文件失败: %v", err),
-				Timestamp:    time.Now(),
-			}
+			return []*models.ScanResult{newErrorResult(template, target, fmt.Sprintf("读取模板文件失败: %v", err))}
 		}
 		template.Content = string(content)
 	}
 
 	if template.Content == "" {
-		return &models.ScanResult{
-			ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
-			TemplateID:   template.ID,
-			TemplateName: template.Name,
-			Severity:     template.Severity,
-			Host:         target,
-			Error:        "模板内容为空",
-			Timestamp:    time.Now(),
-		}
+		return []*models.ScanResult{newErrorResult(template, target, "模板内容为空")}
+	}
+
+	// 非 HTTP 协议模板（dns 等）
+	if results, handled := s.executeProtocolTemplate(ctx, job, info, template); handled {
+		return results
 	}
 
 	// 使用 YAML 反序列化解析 HTTP 请求配置
@@ -462,68 +796,305 @@ func (s *Scanner) executeTemplate(ctx context.Context, client *http.Client, targ
 		requests = parseHTTPRequestsLegacy(template.Content)
 	}
 	if len(requests) == 0 {
-		return &models.ScanResult{
-			ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
-			TemplateID:   template.ID,
-			TemplateName: template.Name,
-			Severity:     template.Severity,
-			Host:         target,
-			Error:        "无法解析模板中的 HTTP 请求",
-			Timestamp:    time.Now(),
-		}
+		return []*models.ScanResult{newErrorResult(template, target, "无法解析模板中的 HTTP 请求")}
 	}
 
-	// 规范化目标 URL
-	target = normalizeTarget(target)
-
 	// 响应体大小限制
-	respLimit := int64(maxResponseSize)
+	respLimit := int64(job.Options.MaxResponseSize)
 	if respLimit <= 0 {
 		respLimit = DefaultMaxRespSize
 	}
 
+	// 同一模板的多个请求共享执行上下文（变量、历史响应、cookie）
+	tctx := newTemplateContext(client, requests, info)
+	tctx.job = job
+	decl := parseTemplateVariables(template.Content)
+	if variablesUseOAST(decl) {
+		// variables 块引用的 interactsh-url 需要在解析变量之前生成
+		if err := s.bindTemplateOASTURL(tctx); err != nil {
+			return []*models.ScanResult{newErrorResult(template, target, err.Error())}
+		}
+	}
+	if err := resolveTemplateVariables(decl, tctx.vars); err != nil {
+		return []*models.ScanResult{newErrorResult(template, target, err.Error())}
+	}
+
+	var results []*models.ScanResult
 	for _, reqConfig := range requests {
-		// 构建并发送请求
-		result := s.sendRequest(ctx, client, target, template, reqConfig, respLimit)
-		if result != nil && result.Matched != "" {
-			return result
+		// 依次发送请求块中的每个 path
+		matched, err := s.executeHTTPRequest(ctx, tctx, job, info, template, reqConfig, respLimit)
+		results = append(results, matched...)
+		if err != nil {
+			// 模板本身有问题（如变量未解析），记录错误而不是发送错误的请求
+			results = append(results, s.resolveOASTChecks(ctx, tctx)...)
+			results = append(results, newErrorResult(template, target, err.Error()))
+			return results
+		}
+		if len(matched) > 0 && reqConfig.StopAtFirstMatch {
+			break
 		}
 		// 如果请求失败，继续尝试下一个（多步请求链）
 	}
 
-	return &models.ScanResult{
-		ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
-		TemplateID:   template.ID,
-		TemplateName: template.Name,
-		Severity:     template.Severity,
-		Host:         target,
-		Error:        "所有请求均未匹配或失败",
-		Timestamp:    time.Now(),
+	// 依赖带外交互的请求在全部请求发送后统一等待
+	results = append(results, s.resolveOASTChecks(ctx, tctx)...)
+	return finishResults(results, template, target, tctx.lastErr)
+}
+
+// executeHTTPRequest 执行单个 http 请求块，定义了 payloads 时对每个 payload 组合各执行一轮
+// 返回的 error 仅表示模板级错误（如变量无法解析），单个请求的网络错误不会中断执行
+func (s *Scanner) executeHTTPRequest(ctx context.Context, tctx *templateContext, job *ScanJob, target *targetInfo, template models.POCTemplate, reqConfig HTTPRequest, respLimit int64) ([]*models.ScanResult, error) {
+	combos, err := job.payloads.combos(reqConfig, template, job.TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(combos) == 0 {
+		return s.executeHTTPRound(ctx, tctx, target, template, reqConfig, respLimit)
+	}
+
+	var results []*models.ScanResult
+	defer func() { tctx.payload = nil }()
+	for _, combo := range combos {
+		if ctx.Err() != nil {
+			break
+		}
+		tctx.payload = combo
+		matched, err := s.executeHTTPRound(ctx, tctx, target, template, reqConfig, respLimit)
+		results = append(results, matched...)
+		if err != nil {
+			return results, err
+		}
+		if len(matched) > 0 && reqConfig.StopAtFirstMatch {
+			break
+		}
+	}
+	return results, nil
+}
+
+// executeHTTPRound 发送请求块中的每个 path 并分别匹配
+// req-condition 模式下先发送全部请求，再用 body_1 / status_code_2 等带序号的响应统一匹配
+func (s *Scanner) executeHTTPRound(ctx context.Context, tctx *templateContext, target *targetInfo, template models.POCTemplate, reqConfig HTTPRequest, respLimit int64) ([]*models.ScanResult, error) {
+	inputs := requestInputs(reqConfig)
+	// 多步请求链中没有 matcher 的步骤只用于提取变量 / 建立会话，不产生结果
+	matchable := len(reqConfig.Matchers) > 0 || !tctx.chained
+
+	var results []*models.ScanResult
+	var requestLog, responseLog []string
+	var lastEvent map[string]interface{}
+
+	for _, input := range inputs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		sendConfig, sendInput := reqConfig, input
+		if reqConfig.TimeDelay != nil {
+			sendConfig, sendInput = withSleepTime(reqConfig, input, reqConfig.TimeDelay.SleepDuration)
+		}
+		// 每个请求使用独立的 interactsh-url
+		vars := tctx.variables()
+		oastIDs, err := s.bindOASTURL(tctx.job.ID, vars, sendConfig, sendInput)
+		if err != nil {
+			return results, err
+		}
+		ex, err := s.sendRequest(ctx, jobLimiter(tctx.job), tctx.client, target, sendConfig, sendInput, vars, respLimit)
+		if err != nil {
+			if errors.Is(err, errUnresolvedVariable) {
+				return results, err
+			}
+			// 单个请求失败不影响后续 path / raw 请求，没有命中时报告最后一次失败原因
+			tctx.lastErr = err
+			continue
+		}
+
+		tctx.record(ex.event)
+		tctx.applyExtractors(reqConfig.Extractors, tctx.matchEvent(ex.event))
+
+		if reqConfig.ReqCondition {
+			// 记录带序号的响应，最后统一匹配
+			lastEvent = ex.event
+			requestLog = append(requestLog, ex.request)
+			responseLog = append(responseLog, ex.response)
+			continue
+		}
+		if !matchable {
+			continue
+		}
+
+		matchers := tctx.expandMatchers(reqConfig.Matchers)
+		event := tctx.matchEvent(ex.event)
+		var matched bool
+		var hit matchHit
+		if ids := append(oastIDs, tctx.oastIDs...); len(ids) > 0 && matchersUseOAST(matchers) {
+			pending := newScanResult(template, target.BaseURL)
+			pending.ExtractedData = tctx.resultExtracted()
+			pending.Request = ex.request
+			pending.Response = ex.response
+			check := oastCheck{ids: ids, matchers: matchers, event: event, cond: reqConfig.MatchersCondition, result: pending}
+			if reqConfig.TimeDelay == nil {
+				// 模板的请求全部发送后统一等待目标回连（见 resolveOASTChecks），不在每个请求后阻塞
+				tctx.oastChecks = append(tctx.oastChecks, check)
+				continue
+			}
+			// 时间盲注需要先确认命中再验证延时，只能立即等待
+			hit = s.waitInteractions(ctx, []oastCheck{check})[0]
+			matched = hit.info != ""
+			if !matched {
+				s.watchLateInteractions(tctx.job.ID, check)
+			}
+		} else {
+			matched, hit = checkMatchers(matchers, event, reqConfig.MatchersCondition)
+		}
+		if !matched {
+			// 未匹配，跳过（仅保存匹配成功的请求/响应包）
+			continue
+		}
+		if reqConfig.TimeDelay != nil {
+			// 时间盲注：以不同延时重复验证，排除网络抖动
+			confirmed, info := s.confirmTimeDelay(ctx, tctx, target, reqConfig, input, respLimit)
+			if !confirmed {
+				continue
+			}
+			hit.info += "; " + info
+		}
+		result := newScanResult(template, target.BaseURL)
+		result.Matched = hit.info
+		result.MatcherNames = hit.names
+		result.ExtractedData = tctx.resultExtracted()
+		result.Request = ex.request
+		result.Response = ex.response
+		results = append(results, result)
+
+		if reqConfig.StopAtFirstMatch {
+			break
+		}
+	}
+
+	if reqConfig.ReqCondition && matchable && lastEvent != nil {
+		matchers := tctx.expandMatchers(reqConfig.Matchers)
+		matched, hit := checkMatchers(matchers, tctx.matchEvent(lastEvent), reqConfig.MatchersCondition)
+		if matched {
+			result := newScanResult(template, target.BaseURL)
+			result.Matched = hit.info
+			result.MatcherNames = hit.names
+			result.ExtractedData = tctx.resultExtracted()
+			result.Request = strings.Join(requestLog, "\n---\n")
+			result.Response = strings.Join(responseLog, "\n---\n")
+			results = append(results, result)
+		}
 	}
+
+	return results, nil
 }
 
-// sendRequest 构建并发送单个 HTTP 请求
-func (s *Scanner) sendRequest(ctx context.Context, client *http.Client, target string, template models.POCTemplate, reqConfig HTTPRequest, respLimit int64) *models.ScanResult {
-	hostname := extractHostname(target)
+// httpExchange 一次 HTTP 请求/响应的记录
+type httpExchange struct {
+	event    map[string]interface{} // 供 matcher/extractor 使用的响应数据
+	request  string                 // 格式化后的请求包
+	response string                 // 格式化后的响应包（截断）
+}
+
+// sendRequest 构建并发送单个 HTTP 请求（input 为 path，raw 请求块中为完整的 raw 请求）
+// 发送前按扫描的速率限制等待
+func (s *Scanner) sendRequest(ctx context.Context, limiter *rateLimiter, client *http.Client, target *targetInfo, reqConfig HTTPRequest, input string, vars map[string]interface{}, respLimit int64) (*httpExchange, error) {
+	if err := limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	client = requestClient(client, reqConfig)
+	if reqConfig.MaxSize > 0 {
+		respLimit = int64(reqConfig.MaxSize)
+	}
+	if len(reqConfig.Raw) > 0 {
+		return s.sendRawRequest(ctx, client, target, reqConfig, input, vars, respLimit)
+	}
 
 	// 展开变量
-	path := expandVariables(reqConfig.Path, target, hostname)
-	if path == "" {
-		path = "/"
+	path, err := expandVariables(input, vars)
+	if err != nil {
+		return nil, err
 	}
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
+
+	// {{BaseURL}}/x、{{RootURL}}/x 展开后已是完整 URL，相对路径拼接在目标路径之后
+	fullURL := target.resolveURL(path)
+
+	body, err := expandVariables(reqConfig.Body, vars)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(reqConfig.Headers))
+	for k, v := range reqConfig.Headers {
+		if headers[k], err = expandVariables(v, vars); err != nil {
+			return nil, err
+		}
 	}
+	return s.doHTTPRequest(ctx, client, reqConfig.Method, fullURL, headers, body, respLimit)
+}
 
-	fullURL := target + path
-	fullURL = expandVariables(fullURL, target, hostname)
+// requestClient 按请求块的 redirects / host-redirects / max-redirects 设置重定向策略
+// 默认不跟随重定向，直接匹配 3xx 响应（开放重定向等模板依赖 Location 头）
+// 连接复用：请求块的 keep-alive 优先于扫描的 KeepAlive 设置；模板显式设置的 Connection 头对该请求最终生效
+func requestClient(client *http.Client, reqConfig HTTPRequest) *http.Client {
+	c := *client
+	if t, ok := c.Transport.(*keepAliveTransport); ok {
+		keepAlive := t.keepAlive
+		if reqConfig.KeepAlive != nil {
+			keepAlive = *reqConfig.KeepAlive
+		}
+		c.Transport = t.choose(keepAlive)
+	}
+	maxRedirects := reqConfig.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = DefaultMaxRedirects
+	}
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !reqConfig.Redirects && !reqConfig.HostRedirects {
+			return http.ErrUseLastResponse
+		}
+		if reqConfig.HostRedirects && !reqConfig.Redirects && req.URL.Host != via[0].URL.Host {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			// 超过次数时停在最后一个 3xx 响应上，而不是整体失败
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+	return &c
+}
+
+// keepAliveTransport 扫描共用的两个传输层：复用连接和每个请求使用新连接，请求块按 keep-alive 选择其一
+type keepAliveTransport struct {
+	reuse     *http.Transport
+	noReuse   *http.Transport
+	keepAlive bool // 扫描的 KeepAlive 设置
+}
+
+// choose 按是否复用连接选择传输层
+func (t *keepAliveTransport) choose(keepAlive bool) *http.Transport {
+	if keepAlive {
+		return t.reuse
+	}
+	return t.noReuse
+}
+
+// RoundTrip 使用扫描设置对应的传输层（经过 requestClient 的请求直接使用选定的传输层）
+func (t *keepAliveTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.choose(t.keepAlive).RoundTrip(req)
+}
+
+// keepAliveEnabled 判断客户端是否复用连接
+func keepAliveEnabled(client *http.Client) bool {
+	t, ok := client.Transport.(*http.Transport)
+	return ok && !t.DisableKeepAlives
+}
 
-	method := strings.ToUpper(reqConfig.Method)
+// doHTTPRequest 通过 net/http 发送已展开变量的请求
+func (s *Scanner) doHTTPRequest(ctx context.Context, client *http.Client, method, fullURL string, headers map[string]string, body string, respLimit int64) (*httpExchange, error) {
+	method = strings.ToUpper(method)
 	if method == "" {
 		method = "GET"
 	}
 
-	body := expandVariables(reqConfig.Body, target, hostname)
 	var bodyReader io.Reader
 	if body != "" {
 		bodyReader = bytes.NewBufferString(body)
@@ -531,26 +1102,18 @@ func (s *Scanner) sendRequest(ctx context.Context, client *http.Client, target s
 
 	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
 	if err != nil {
-		return &models.ScanResult{
-			ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
-			TemplateID:   template.ID,
-			TemplateName: template.Name,
-			Severity:     template.Severity,
-			Host:         target,
-			Error:        fmt.Sprintf("构造请求失败: %v", err),
-			Timestamp:    time.Now(),
-			Request:      fmt.Sprintf("%s %s", method, fullURL),
-		}
+		return nil, fmt.Errorf("构造请求失败: %v", err)
 	}
 
 	// 设置默认 headers
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
 	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Connection", "close")
+	if !keepAliveEnabled(client) {
+		req.Header.Set("Connection", "close")
+	}
 
-	// 设置自定义 headers（展开变量）
-	for k, v := range reqConfig.Headers {
-		v = expandVariables(v, target, hostname)
+	// 设置自定义 headers（模板可通过 Connection 头为单个请求关闭连接复用）
+	for k, v := range headers {
 		req.Header.Set(k, v)
 		// 自定义 Host header
 		if strings.EqualFold(k, "Host") {
@@ -562,64 +1125,165 @@ func (s *Scanner) sendRequest(ctx context.Context, client *http.Client, target s
 	reqStr := formatRequest(req, body)
 
 	// 发送请求
+	start := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
-		return &models.ScanResult{
-			ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
-			TemplateID:   template.ID,
-			TemplateName: template.Name,
-			Severity:     template.Severity,
-			Host:         target,
-			Error:        fmt.Sprintf("请求失败: %v", err),
-			Timestamp:    time.Now(),
-			Request:      reqStr,
-		}
+		return nil, fmt.Errorf("请求失败: %v", err)
 	}
 
 	// 读取响应
 	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, respLimit))
 	resp.Body.Close()
 
-	respStr := formatResponse(resp, respBody)
+	event := buildHTTPEvent(resp, respBody)
+	event["duration"] = time.Since(start).Seconds()
+
+	return &httpExchange{
+		event:    event,
+		request:  reqStr,
+		response: formatResponse(resp, respBody),
+	}, nil
+}
+
+// buildHTTPEvent 将 HTTP 响应转换为按 part 取值的数据表
+func buildHTTPEvent(resp *http.Response, body []byte) map[string]interface{} {
+	bodyStr := string(body)
+	headerStr := formatHeaders(resp.Header)
+	headers := make(map[string]string, len(resp.Header))
+	for k, v := range resp.Header {
+		headers[normalizeKVKey(k)] = strings.Join(v, ", ")
+	}
+	cookies := make(map[string]string)
+	for _, c := range resp.Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	raw := fmt.Sprintf("HTTP/%d.%d %s\r\n", resp.ProtoMajor, resp.ProtoMinor, resp.Status) +
+		strings.ReplaceAll(headerStr, "\n", "\r\n") + "\r\n" + bodyStr
+	return map[string]interface{}{
+		"body":           bodyStr,
+		"header":         headerStr,
+		"all":            headerStr + "\n" + bodyStr,
+		"raw":            raw,
+		"status_code":    resp.StatusCode,
+		"content_length": len(body),
+		"headers":        headers, // 规范化名称（content_type）-> 值
+		"cookies":        cookies, // Set-Cookie 名称 -> 值
+	}
+}
+
+// newScanResult 创建模板在目标上的基础结果
+func newScanResult(template models.POCTemplate, target string) *models.ScanResult {
+	return &models.ScanResult{
+		ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
+		TemplateID:   template.ID,
+		TemplateName: template.Name,
+		Severity:     template.Severity,
+		Host:         target,
+		Timestamp:    time.Now(),
+	}
+}
+
+// newErrorResult 创建带错误信息的结果
+func newErrorResult(template models.POCTemplate, target, msg string) *models.ScanResult {
+	result := newScanResult(template, target)
+	result.Error = msg
+	return result
+}
 
-	// 提取数据
-	extracted := make(map[string]string)
-	for _, ext := range reqConfig.Extractors {
-		if data := runExtractor(ext, string(respBody), resp.Header); data != nil {
-			for k, v := range data {
-				extracted[k] = v
+// findingPairsLocked 其他扫描中已有发现的 模板 + 目标（调用方持有 s.mu）
+// 扫描只为这些组合记录收到响应但未命中，不必保存全部 目标 × 模板 的未命中组合
+func (s *Scanner) findingPairsLocked(scanID string) map[string]bool {
+	pairs := make(map[string]bool)
+	for id, results := range s.results {
+		if id == scanID {
+			continue
+		}
+		for _, r := range results {
+			if r.Matched != "" {
+				pairs[templateHostKey(r.TemplateID, r.Host)] = true
 			}
 		}
 	}
+	return pairs
+}
 
-	// 检查匹配条件
-	matched, matchInfo := checkMatchers(reqConfig.Matchers, resp, respBody, reqConfig.MatchersCondition)
-	if matched {
-		return &models.ScanResult{
-			ID:            fmt.Sprintf("%d", time.Now().UnixNano()),
-			TemplateID:    template.ID,
-			TemplateName:  template.Name,
-			Severity:      template.Severity,
-			Host:          target,
-			Matched:       matchInfo,
-			ExtractedData: extracted,
-			Timestamp:     time.Now(),
-			Request:       reqStr,
-			Response:      respStr,
+// markCheckedLocked 记录扫描中收到响应但未命中的已有发现（调用方持有 s.mu）
+func (s *Scanner) markCheckedLocked(scanID string, c checkedTemplate) {
+	if s.checked == nil {
+		s.checked = make(map[string]map[string]bool)
+	}
+	if s.checked[scanID] == nil {
+		s.checked[scanID] = make(map[string]bool)
+	}
+	s.checked[scanID][templateHostKey(c.TemplateID, c.Host)] = true
+}
+
+// isNoMatch 判断结果是否为“收到响应但未命中”（区别于目标无法访问等请求失败）
+func isNoMatch(result *models.ScanResult) bool {
+	return result.Matched == "" && result.Error == msgNoMatch
+}
+
+// hasOnlyErrors 判断结果是否全部为错误（用于决定是否重试）
+func hasOnlyErrors(results []*models.ScanResult) bool {
+	if len(results) == 0 {
+		return false
+	}
+	for _, r := range results {
+		if r == nil || r.Error == "" {
+			return false
 		}
 	}
+	return true
+}
 
-	// 未匹配，跳过（仅保存匹配成功的请求/响应包）
-	return nil
+// countTemplateRequests 统计模板在单个目标上会发送的请求数（path 数 × payload 组合数）
+func countTemplateRequests(template models.POCTemplate, templatesDir string) int {
+	content := template.Content
+	if content == "" && template.FilePath != "" {
+		data, err := os.ReadFile(template.FilePath)
+		if err != nil {
+			return 1
+		}
+		content = string(data)
+	}
+	if n := countProtocolRequests(content); n > 0 {
+		return n
+	}
+	requests, err := parseHTTPRequestsYAML(content)
+	if err != nil || len(requests) == 0 {
+		requests = parseHTTPRequestsLegacy(content)
+	}
+	count := 0
+	for _, r := range requests {
+		n := len(requestInputs(r))
+		if combos, err := requestPayloadCombos(r, template.FilePath, templatesDir); err == nil && len(combos) > 0 {
+			n *= len(combos)
+		}
+		count += n
+	}
+	if count == 0 {
+		return 1
+	}
+	return count
 }
 
-// normalizeTarget 规范化目标 URL
+// requestInputs 请求块中需要逐个发送的请求：raw 请求块为各个 raw 请求，否则为各个 path
+func requestInputs(r HTTPRequest) []string {
+	if len(r.Raw) > 0 {
+		return r.Raw
+	}
+	if len(r.Paths) == 0 {
+		return []string{""}
+	}
+	return r.Paths
+}
+
+// normalizeTarget 规范化目标 URL（补全 scheme，保留结尾的 /，parseTarget 据此区分目录和文件）
 func normalizeTarget(target string) string {
 	target = strings.TrimSpace(target)
 	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
 		target = "http://" + target
 	}
-	target = strings.TrimSuffix(target, "/")
 	return target
 }
 
@@ -670,27 +1334,6 @@ func validateTarget(target string) error {
 	return nil
 }
 
-// expandVariables 展开 Nuclei 模板变量
-func expandVariables(s string, target, hostname string) string {
-	if s == "" {
-		return s
-	}
-	s = strings.ReplaceAll(s, "{{BaseURL}}", target)
-	s = strings.ReplaceAll(s, "{{RootURL}}", target)
-	s = strings.ReplaceAll(s, "{{Hostname}}", hostname)
-	s = strings.ReplaceAll(s, "{{Host}}", hostname)
-	s = strings.ReplaceAll(s, "{{Scheme}}", extractScheme(target))
-
-	// 动态变量
-	s = strings.ReplaceAll(s, "{{randstr}}", randStr(8))
-	s = strings.ReplaceAll(s, "{{randstr_10}}", randStr(10))
-	s = strings.ReplaceAll(s, "{{randint}}", fmt.Sprintf("%d", rand.Intn(999999)))
-	s = strings.ReplaceAll(s, "{{unix_time}}", fmt.Sprintf("%d", time.Now().Unix()))
-	s = strings.ReplaceAll(s, "{{timestamp}}", fmt.Sprintf("%d", time.Now().Unix()))
-
-	return s
-}
-
 // randStr 生成随机字符串
 func randStr(n int) string {
 	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
@@ -701,50 +1344,64 @@ func randStr(n int) string {
 	return string(b)
 }
 
-func extractScheme(target string) string {
-	if strings.HasPrefix(target, "https://") {
-		return "https"
-	}
-	return "http"
-}
-
-// extractHostname 从 URL 中提取主机名
-func extractHostname(rawURL string) string {
-	rawURL = strings.TrimPrefix(rawURL, "http://")
-	rawURL = strings.TrimPrefix(rawURL, "https://")
-	parts := strings.Split(rawURL, "/")
-	return parts[0]
-}
-
 // HTTPRequest HTTP 请求配置
+
 type HTTPRequest struct {
 	Method            string
-	Path              string
+	Paths             []string // 请求路径列表（每个 path 单独发送）
 	Headers           map[string]string
 	Body              string
 	Matchers          []Matcher
-	MatchersCondition string    // "and" or "or", 默认为 "or"
+	MatchersCondition string // "and" or "or", 默认为 "or"
 	Extractors        []Extractor
+	StopAtFirstMatch  bool                   // 命中后不再发送剩余请求
+	ReqCondition      bool                   // 全部请求完成后统一匹配（支持 body_1、status_code_2 等）
+	CookieReuse       bool                   // 模板内多个请求共享 cookie
+	Payloads          map[string]interface{} // payload 定义：内联列表或字典文件路径
+	AttackType        string                 // batteringram, pitchfork, clusterbomb
+	Raw               []string               // raw 请求（每个单独发送，发送前展开变量）
+	Unsafe            bool                   // raw 请求原样写入 TCP/TLS 连接，不经过 net/http 规范化
+	TimeDelay         *TimeDelay             // 时间盲注确认（命中后以不同延时重复验证）
+	Redirects         bool                   // 跟随重定向（默认不跟随，匹配第一个响应）
+	HostRedirects     bool                   // 只跟随同一主机内的重定向
+	MaxRedirects      int                    // 最大重定向次数（0 表示 DefaultMaxRedirects）
+	MaxSize           int                    // 响应体最大读取字节数（0 表示使用扫描设置）
+	ReadAll           bool                   // unsafe 请求一直读取到连接关闭，忽略 Content-Length
+	KeepAlive         *bool                  // 复用连接（nil 表示使用扫描的 KeepAlive 设置），见 requestClient
 }
 
 // Matcher 匹配器
+
 type Matcher struct {
-	Type      string   // status, word, regex
-	Words     []string
-	Status    []int
-	Regex     []string
-	Part      string // body, header, all
-	Condition string // and, or (matcher 内部多个 word/regex 之间的条件)
-	Negative  bool
+	Name            string // 命名 matcher（工作流按名称选择子模板）
+	Type            string // status, size, word, binary, regex, dsl
+	Words           []string
+	Status          []int
+	Size            []int    // part 内容长度
+	Binary          []string // 十六进制编码的二进制内容
+	Regex           []string
+	DSL             []string // dsl 表达式（如 contains(body, "x") && status_code == 200）
+	Part            string   // body, header, all, raw, status_code, content_length, duration, 响应头名称，以及 req-condition 下的 body_1、header_2 等
+	Condition       string   // and, or (matcher 内部多个 word/regex 之间的条件)
+	Negative        bool
+	CaseInsensitive bool   // word 匹配忽略大小写
+	MatchAll        bool   // 记录全部命中内容，而不是第一个命中即停止
+	Encoding        string // words 的编码方式（hex）
 }
 
 // Extractor 数据提取器
 type Extractor struct {
-	Type   string   // regex, kval, json
-	Regex  []string // 正则表达式
-	Part   string   // body, header
-	KVal   []string // key:value 提取
-	Name   string   // 提取器名称
+	Type      string   // regex, kval, json, xpath, dsl
+	Regex     []string // 正则表达式
+	Group     *int     // regex 捕获组（未设置时有捕获组取第 1 组，否则取整个匹配）
+	DSL       []string // dsl 表达式，结果作为提取值
+	JSON      []string // jq 风格的 JSON 查询
+	XPath     []string // HTML / XML 的 XPath 查询
+	Attribute string   // xpath 提取的属性名（为空时取节点文本）
+	Part      string   // body, header, cookie
+	KVal      []string // 响应头 / cookie 名称（如 content_type、PHPSESSID）
+	Name      string   // 提取器名称（命名结果可作为后续请求的变量）
+	Internal  bool     // 仅作为变量使用，不展示在结果中
 }
 
 // —— YAML 解析结构 ——
@@ -759,39 +1416,73 @@ type nucleiYAML struct {
 		Description string `yaml:"description"`
 		Tags        string `yaml:"tags"`
 	} `yaml:"info"`
-	HTTP []nucleiHTTPReq `yaml:"http"`
+	HTTP      []nucleiHTTPReq      `yaml:"http"`
+	DNS       []nucleiDNSReq       `yaml:"dns"`
+	Network   []nucleiNetworkReq   `yaml:"network"`
+	TCP       []nucleiNetworkReq   `yaml:"tcp"` // network 的旧写法
+	SSL       []nucleiSSLReq       `yaml:"ssl"`
+	WebSocket []nucleiWebSocketReq `yaml:"websocket"`
+	File      []nucleiFileReq      `yaml:"file"`
 }
 
 // nucleiHTTPReq Nuclei HTTP 请求结构
+
 type nucleiHTTPReq struct {
-	Method            string            `yaml:"method"`
-	Path              []string          `yaml:"path"`
-	Raw               []string          `yaml:"raw"`
-	Headers           map[string]string `yaml:"headers"`
-	Body              string            `yaml:"body"`
-	MatchersCondition string            `yaml:"matchers-condition"`
-	Matchers          []nucleiMatcher   `yaml:"matchers"`
-	Extractors        []nucleiExtractor `yaml:"extractors"`
+	Method            string                 `yaml:"method"`
+	Path              []string               `yaml:"path"`
+	Raw               []string               `yaml:"raw"`
+	Headers           map[string]string      `yaml:"headers"`
+	Body              string                 `yaml:"body"`
+	MatchersCondition string                 `yaml:"matchers-condition"`
+	Matchers          []nucleiMatcher        `yaml:"matchers"`
+	Extractors        []nucleiExtractor      `yaml:"extractors"`
+	StopAtFirstMatch  bool                   `yaml:"stop-at-first-match"`
+	ReqCondition      bool                   `yaml:"req-condition"`
+	CookieReuse       bool                   `yaml:"cookie-reuse"`
+	Payloads          map[string]interface{} `yaml:"payloads"`
+	Attack            string                 `yaml:"attack"`
+	Unsafe            bool                   `yaml:"unsafe"`
+	Analyzer          *nucleiAnalyzer        `yaml:"analyzer"`
+	Redirects         bool                   `yaml:"redirects"`
+	HostRedirects     bool                   `yaml:"host-redirects"`
+	MaxRedirects      int                    `yaml:"max-redirects"`
+	MaxSize           int                    `yaml:"max-size"`
+	ReadAll           bool                   `yaml:"read-all"`
+	KeepAlive         *bool                  `yaml:"keep-alive"`
 }
 
 // nucleiMatcher Nuclei 匹配器结构
+
 type nucleiMatcher struct {
-	Type      string   `yaml:"type"`
-	Words     []string `yaml:"words"`
-	Status    []int    `yaml:"status"`
-	Regex     []string `yaml:"regex"`
-	Part      string   `yaml:"part"`
-	Condition string   `yaml:"condition"`
-	Negative  bool     `yaml:"negative"`
+	Name            string   `yaml:"name"`
+	Type            string   `yaml:"type"`
+	Words           []string `yaml:"words"`
+	Status          []int    `yaml:"status"`
+	Size            []int    `yaml:"size"`
+	Binary          []string `yaml:"binary"`
+	Regex           []string `yaml:"regex"`
+	DSL             []string `yaml:"dsl"`
+	Part            string   `yaml:"part"`
+	Condition       string   `yaml:"condition"`
+	Negative        bool     `yaml:"negative"`
+	CaseInsensitive bool     `yaml:"case-insensitive"`
+	MatchAll        bool     `yaml:"match-all"`
+	Encoding        string   `yaml:"encoding"`
 }
 
 // nucleiExtractor Nuclei 提取器结构
 type nucleiExtractor struct {
-	Type  string   `yaml:"type"`
-	Regex []string `yaml:"regex"`
-	Part  string   `yaml:"part"`
-	KVal  []string `yaml:"kval"`
-	Name  string   `yaml:"name"`
+	Type      string   `yaml:"type"`
+	Regex     []string `yaml:"regex"`
+	Group     *int     `yaml:"group"`
+	DSL       []string `yaml:"dsl"`
+	JSON      []string `yaml:"json"`
+	XPath     []string `yaml:"xpath"`
+	Attribute string   `yaml:"attribute"`
+	Part      string   `yaml:"part"`
+	KVal      []string `yaml:"kval"`
+	Name      string   `yaml:"name"`
+	Internal  bool     `yaml:"internal"`
 }
 
 // parseHTTPRequestsYAML 使用 YAML 反序列化解析 HTTP 请求（新解析器）
@@ -806,8 +1497,21 @@ func parseHTTPRequestsYAML(content string) ([]HTTPRequest, error) {
 		req := HTTPRequest{
 			Method:            nhr.Method,
 			Headers:           make(map[string]string),
-			Matchers:          make([]Matcher, 0),
 			MatchersCondition: nhr.MatchersCondition,
+			StopAtFirstMatch:  nhr.StopAtFirstMatch,
+			ReqCondition:      nhr.ReqCondition,
+			CookieReuse:       nhr.CookieReuse,
+			Payloads:          nhr.Payloads,
+			AttackType:        nhr.Attack,
+			Raw:               nhr.Raw,
+			Unsafe:            nhr.Unsafe,
+			TimeDelay:         parseTimeDelay(nhr.Analyzer),
+			Redirects:         nhr.Redirects,
+			HostRedirects:     nhr.HostRedirects,
+			MaxRedirects:      nhr.MaxRedirects,
+			MaxSize:           nhr.MaxSize,
+			ReadAll:           nhr.ReadAll,
+			KeepAlive:         nhr.KeepAlive,
 		}
 
 		// Headers
@@ -815,44 +1519,16 @@ func parseHTTPRequestsYAML(content string) ([]HTTPRequest, error) {
 			req.Headers[k] = v
 		}
 
-		// Path
-		if len(nhr.Path) > 0 {
-			req.Path = nhr.Path[0]
-		}
-
-		// Method: 如果是 raw 请求，提取首行
-		if nhr.Method == "" && len(nhr.Raw) > 0 {
-			req.Method, req.Path, req.Headers, req.Body = parseRawRequest(nhr.Raw[0])
-		}
+		// Path（保留全部 path，每个 path 单独发送）
+		req.Paths = append(req.Paths, nhr.Path...)
 
 		// Body
 		if nhr.Body != "" {
 			req.Body = nhr.Body
 		}
 
-		// Matchers
-		for _, nm := range nhr.Matchers {
-			req.Matchers = append(req.Matchers, Matcher{
-				Type:      nm.Type,
-				Words:     nm.Words,
-				Status:    nm.Status,
-				Regex:     nm.Regex,
-				Part:      nm.Part,
-				Condition: nm.Condition,
-				Negative:  nm.Negative,
-			})
-		}
-
-		// Extractors
-		for _, ne := range nhr.Extractors {
-			req.Extractors = append(req.Extractors, Extractor{
-				Type:  ne.Type,
-				Regex: ne.Regex,
-				Part:  ne.Part,
-				KVal:  ne.KVal,
-				Name:  ne.Name,
-			})
-		}
+		req.Matchers = convertMatchers(nhr.Matchers)
+		req.Extractors = convertExtractors(nhr.Extractors)
 
 		requests = append(requests, req)
 	}
@@ -860,13 +1536,59 @@ func parseHTTPRequestsYAML(content string) ([]HTTPRequest, error) {
 	return requests, nil
 }
 
+// convertMatchers 将 YAML 中的 matcher 转换为内部结构（各协议通用）
+func convertMatchers(nms []nucleiMatcher) []Matcher {
+	matchers := make([]Matcher, 0, len(nms))
+	for _, nm := range nms {
+		matchers = append(matchers, Matcher{
+			Name:            nm.Name,
+			Type:            nm.Type,
+			Words:           nm.Words,
+			Status:          nm.Status,
+			Size:            nm.Size,
+			Binary:          nm.Binary,
+			Regex:           nm.Regex,
+			DSL:             nm.DSL,
+			Part:            nm.Part,
+			Condition:       nm.Condition,
+			Negative:        nm.Negative,
+			CaseInsensitive: nm.CaseInsensitive,
+			MatchAll:        nm.MatchAll,
+			Encoding:        nm.Encoding,
+		})
+	}
+	return matchers
+}
+
+// convertExtractors 将 YAML 中的 extractor 转换为内部结构（各协议通用）
+func convertExtractors(nes []nucleiExtractor) []Extractor {
+	var extractors []Extractor
+	for _, ne := range nes {
+		extractors = append(extractors, Extractor{
+			Type:      ne.Type,
+			Regex:     ne.Regex,
+			Group:     ne.Group,
+			DSL:       ne.DSL,
+			JSON:      ne.JSON,
+			XPath:     ne.XPath,
+			Attribute: ne.Attribute,
+			Part:      ne.Part,
+			KVal:      ne.KVal,
+			Name:      ne.Name,
+			Internal:  ne.Internal,
+		})
+	}
+	return extractors
+}
+
 // parseRawRequest 解析 Raw HTTP 请求字符串
+// 请求体按原样保留（仅去掉 YAML 块末尾的换行），Content-Length 由 net/http 重新计算
 func parseRawRequest(raw string) (method, path string, headers map[string]string, body string) {
 	headers = make(map[string]string)
-	lines := strings.Split(raw, "\n")
-	if len(lines) == 0 {
-		return
-	}
+	raw = strings.TrimLeft(strings.ReplaceAll(raw, "\r\n", "\n"), "\n\t ")
+	head, body, _ := strings.Cut(raw, "\n\n")
+	body = strings.TrimSuffix(body, "\n")
+	lines := strings.Split(head, "\n")
 
 	// 解析请求行: METHOD /path HTTP/1.1
 	firstLine := strings.TrimSpace(lines[0])
@@ -876,85 +1598,79 @@ func parseRawRequest(raw string) (method, path string, headers map[string]string
 		path = strings.TrimSpace(parts[1])
 	}
 
-	// 解析 headers 和 body
-	inBody := false
-	for i := 1; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-		if line == "" || line == "\r" {
-			inBody = true
-			continue
-		}
-		if !inBody {
-			if idx := strings.Index(line, ":"); idx > 0 {
-				key := strings.TrimSpace(line[:idx])
-				val := strings.TrimSpace(line[idx+1:])
-				headers[key] = val
-			}
-		} else {
-			if body != "" {
-				body += "\n"
+	// 解析 headers
+	for _, line := range lines[1:] {
+		if idx := strings.Index(line, ":"); idx > 0 {
+			key := strings.TrimSpace(line[:idx])
+			if strings.EqualFold(key, "Content-Length") {
+				continue
 			}
-			body += line
+			headers[key] = strings.TrimSpace(line[idx+1:])
 		}
 	}
 
 	return
 }
 
-// runExtractor 执行数据提取
-func runExtractor(ext Extractor, body string, headers http.Header) map[string]string {
-	result := make(map[string]string)
+// runExtractor 执行数据提取，返回提取到的全部值（去重，保持顺序）
+func runExtractor(ext Extractor, event map[string]interface{}) []string {
+	var values []string
+	seen := make(map[string]bool)
+	add := func(v string) {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
 
 	switch ext.Type {
 	case "regex":
 		// 选择匹配内容
-		content := body
-		if ext.Part == "header" {
-			content = formatHeaders(headers)
-		}
+		content := eventPart(event, ext.Part)
 		for _, pattern := range ext.Regex {
-			re, err := regexp.Compile(pattern)
+			re, err := compileRegexCached(pattern)
 			if err != nil {
 				continue
 			}
-			matches := re.FindStringSubmatch(content)
-			if len(matches) > 1 {
-				name := ext.Name
-				if name == "" {
-					name = fmt.Sprintf("extract_%d", len(result))
+			group := 0
+			if ext.Group != nil {
+				group = *ext.Group
+			} else if re.NumSubexp() > 0 {
+				group = 1
+			}
+			for _, m := range re.FindAllStringSubmatch(content, -1) {
+				if group < len(m) {
+					add(m[group])
 				}
-				result[name] = matches[1]
 			}
 		}
 	case "kval":
-		// key:value 提取（从 body 或 headers）
-		content := body
-		if ext.Part == "header" {
-			content = formatHeaders(headers)
-		}
-		for _, kv := range ext.KVal {
-			if idx := strings.Index(content, kv); idx >= 0 {
-				name := ext.Name
-				if name == "" {
-					name = fmt.Sprintf("extract_%d", len(result))
-				}
-				// 提取 "key: value" 中冒号后的值
-				lineEnd := strings.Index(content[idx:], "\n")
-				if lineEnd == -1 {
-					lineEnd = len(content) - idx
-				}
-				line := content[idx : idx+lineEnd]
-				if colonIdx := strings.Index(line, ":"); colonIdx > 0 {
-					result[name] = strings.TrimSpace(line[colonIdx+1:])
-				}
+		// 按名称读取响应头 / cookie
+		for _, key := range ext.KVal {
+			for _, v := range kvalLookup(event, ext.Part, key) {
+				add(v)
 			}
 		}
+	case "json":
+		for _, v := range extractJSON(eventPart(event, ext.Part), ext.JSON) {
+			add(v)
+		}
+	case "xpath":
+		for _, v := range extractXPath(eventPart(event, ext.Part), ext.XPath, ext.Attribute) {
+			add(v)
+		}
+	case "dsl":
+		// 表达式求值结果作为提取值（空值忽略）
+		for _, expr := range ext.DSL {
+			v, err := evalDSL(expr, event)
+			if err != nil {
+				continue
+			}
+			add(toDSLString(v))
+		}
 	}
 
-	if len(result) == 0 {
-		return nil
-	}
-	return result
+	return values
 }
 
 // parseHTTPRequestsLegacy 旧的逐行解析器（向后兼容）
@@ -985,7 +1701,7 @@ func parseHTTPRequestsLegacy(content string) []HTTPRequest {
 
 		// 检测新的请求块
 		if strings.HasPrefix(trimmed, "- method:") || strings.HasPrefix(trimmed, "- raw:") {
-			if inRequest && (currentReq.Path != "" || currentReq.Method != "") {
+			if inRequest && (len(currentReq.Paths) > 0 || currentReq.Method != "") {
 				requests = append(requests, currentReq)
 			}
 			inRequest = true
@@ -1010,13 +1726,15 @@ func parseHTTPRequestsLegacy(content string) []HTTPRequest {
 		} else if strings.HasPrefix(trimmed, "path:") {
 			pathStr := strings.TrimPrefix(trimmed, "path:")
 			pathStr = strings.Trim(pathStr, " []\"'")
-			currentReq.Path = pathStr
-		} else if strings.HasPrefix(trimmed, "- \"") || strings.HasPrefix(trimmed, "- '") {
+			if pathStr != "" {
+				currentReq.Paths = append(currentReq.Paths, pathStr)
+			}
+		} else if !inMatchers && (strings.HasPrefix(trimmed, "- \"") || strings.HasPrefix(trimmed, "- '")) {
 			path := strings.Trim(trimmed, "- \"'")
 			path = strings.TrimPrefix(path, "{{BaseURL}}")
 			// 如果是其他变量前缀（如 {{RootURL}}），也去掉
 			path = strings.TrimPrefix(path, "{{RootURL}}")
-			currentReq.Path = path
+			currentReq.Paths = append(currentReq.Paths, path)
 		} else if strings.HasPrefix(trimmed, "body:") {
 			currentReq.Body = strings.Trim(strings.TrimPrefix(trimmed, "body:"), " \"'")
 		} else if strings.HasPrefix(trimmed, "matchers-condition:") {
@@ -1082,9 +1800,9 @@ func parseHTTPRequestsLegacy(content string) []HTTPRequest {
 	}
 
 	// 添加最后一个请求
-	if inRequest && (currentReq.Path != "" || currentReq.Method != "" || len(currentReq.Matchers) > 0) {
-		if currentReq.Path == "" {
-			currentReq.Path = "/"
+	if inRequest && (len(currentReq.Paths) > 0 || currentReq.Method != "" || len(currentReq.Matchers) > 0) {
+		if len(currentReq.Paths) == 0 {
+			currentReq.Paths = []string{"/"}
 		}
 		requests = append(requests, currentReq)
 	}
@@ -1137,109 +1855,198 @@ func formatResponse(resp *http.Response, body []byte) string {
 	return sb.String()
 }
 
+// matchHit 命中的 matcher
+type matchHit struct {
+	info  string   // 展示用的匹配信息，如 word(body): admin, root
+	names []string // 命中的命名 matcher（工作流按此判断分支，不从 info 中解析）
+}
+
 // checkMatchers 检查所有匹配器
 // matchersCondition: "and" 表示所有 matcher 都必须匹配, "or"(默认) 表示任一匹配即可
-func checkMatchers(matchers []Matcher, resp *http.Response, body []byte, matchersCondition string) (bool, string) {
+// 返回的匹配信息列出每个命中 matcher 实际命中的内容，如 word(body): admin, root
+func checkMatchers(matchers []Matcher, event map[string]interface{}, matchersCondition string) (bool, matchHit) {
 	if len(matchers) == 0 {
 		// 没有 matcher，默认检查状态码 200
-		if resp.StatusCode == 200 {
-			return true, fmt.Sprintf("Status: %d", resp.StatusCode)
+		if code := eventInt(event, "status_code"); code == 200 {
+			return true, matchHit{info: fmt.Sprintf("Status: %d", code)}
 		}
-		return false, ""
+		return false, matchHit{}
 	}
 
 	if matchersCondition == "" {
 		matchersCondition = "or" // 默认 OR
 	}
 
-	bodyStr := string(body)
-	headerStr := formatHeaders(resp.Header)
-	allStr := headerStr + "\n" + bodyStr
-
-	var matchedInfos []string
+	var matchedInfos, names []string
 
 	for _, m := range matchers {
-		matched := checkSingleMatcher(m, resp, bodyStr, headerStr, allStr)
+		matched, hits := checkSingleMatcher(m, event)
 
 		if m.Negative {
 			matched = !matched
 		}
 
 		if matched {
-			matchedInfos = append(matchedInfos, fmt.Sprintf("Matched: type=%s", m.Type))
+			matchedInfos = append(matchedInfos, describeMatch(m, hits))
+			if m.Name != "" {
+				names = append(names, m.Name)
+			}
 		}
 	}
 
 	if matchersCondition == "and" {
 		// AND: 所有 matcher 都必须匹配
 		if len(matchedInfos) == len(matchers) {
-			return true, strings.Join(matchedInfos, "; ")
+			return true, matchHit{info: strings.Join(matchedInfos, "; "), names: names}
 		}
-		return false, ""
+		return false, matchHit{}
 	}
 
 	// OR: 任一 matcher 匹配即可
 	if len(matchedInfos) > 0 {
-		return true, strings.Join(matchedInfos, "; ")
+		return true, matchHit{info: strings.Join(matchedInfos, "; "), names: names}
 	}
-	return false, ""
+	return false, matchHit{}
 }
 
-// checkSingleMatcher 检查单个匹配器
-func checkSingleMatcher(m Matcher, resp *http.Response, bodyStr, headerStr, allStr string) bool {
+// describeMatch 生成单个命中 matcher 的描述（命名 matcher 以 [name] 开头）
+func describeMatch(m Matcher, hits []string) string {
+	label := m.Type
+	if m.Part != "" && m.Type != "status" && m.Type != "dsl" {
+		label += "(" + m.Part + ")"
+	}
+	if m.Negative {
+		label = "!" + label
+	} else if len(hits) > 0 {
+		label += ": " + strings.Join(hits, ", ")
+	}
+	if m.Name != "" {
+		return "[" + m.Name + "] " + label
+	}
+	return label
+}
+
+// checkSingleMatcher 检查单个匹配器，返回是否匹配以及实际命中的内容
+// match-all 为 false 时 or 条件在第一个命中后即停止
+func checkSingleMatcher(m Matcher, event map[string]interface{}) (bool, []string) {
 	switch m.Type {
 	case "status":
-		for _, code := range m.Status {
-			if resp.StatusCode == code {
-				return true
+		code := eventInt(event, "status_code")
+		for _, c := range m.Status {
+			if code == c {
+				return true, []string{strconv.Itoa(code)}
+			}
+		}
+	case "size":
+		size := len(eventPart(event, m.Part))
+		for _, n := range m.Size {
+			if size == n {
+				return true, []string{strconv.Itoa(size)}
 			}
 		}
 	case "word":
-		content := bodyStr
-		if m.Part == "header" {
-			content = headerStr
-		} else if m.Part == "all" {
-			content = allStr
+		content := eventPart(event, m.Part)
+		if m.CaseInsensitive {
+			content = strings.ToLower(content)
 		}
-
-		if m.Condition == "and" {
-			for _, word := range m.Words {
-				if !strings.Contains(content, word) {
-					return false
+		return matchAll(m, m.Words, func(word string) []string {
+			if m.Encoding == "hex" {
+				decoded, err := hex.DecodeString(word)
+				if err != nil {
+					return nil
 				}
+				word = string(decoded)
 			}
-			return len(m.Words) > 0
-		}
-		// OR: 任意 word 匹配
-		for _, word := range m.Words {
-			if strings.Contains(content, word) {
-				return true
+			if m.CaseInsensitive {
+				word = strings.ToLower(word)
 			}
-		}
+			if word != "" && strings.Contains(content, word) {
+				return []string{word}
+			}
+			return nil
+		})
+	case "binary":
+		content := eventPart(event, m.Part)
+		return matchAll(m, m.Binary, func(b string) []string {
+			decoded, err := hex.DecodeString(b)
+			if err != nil || len(decoded) == 0 || !strings.Contains(content, string(decoded)) {
+				return nil
+			}
+			return []string{b}
+		})
 	case "regex":
-		content := bodyStr
-		if m.Part == "header" {
-			content = headerStr
-		} else if m.Part == "all" {
-			content = allStr
-		}
+		content := eventPart(event, m.Part)
+		return matchAll(m, m.Regex, func(pattern string) []string {
+			return findRegex(content, pattern, m.MatchAll)
+		})
+	case "dsl":
+		// 表达式求值出错（如引用了不存在的变量）视为不匹配
+		return matchAll(m, m.DSL, func(expr string) []string {
+			if ok, err := evalDSLBool(expr, event); err == nil && ok {
+				return []string{expr}
+			}
+			return nil
+		})
+	}
+	return false, nil
+}
 
+// matchAll 按 matcher 内部条件（and / or）逐项匹配，汇总命中内容
+func matchAll(m Matcher, items []string, match func(string) []string) (bool, []string) {
+	var hits []string
+	for _, item := range items {
+		found := match(item)
 		if m.Condition == "and" {
-			for _, pattern := range m.Regex {
-				if !matchRegex(content, pattern) {
-					return false
-				}
+			if len(found) == 0 {
+				return false, nil
 			}
-			return len(m.Regex) > 0
+			hits = append(hits, found...)
+			continue
 		}
-		// OR: 任意 regex 匹配
-		for _, pattern := range m.Regex {
-			if matchRegex(content, pattern) {
-				return true
+		if len(found) > 0 {
+			hits = append(hits, found...)
+			if !m.MatchAll {
+				break
 			}
 		}
 	}
-	return false
+	return len(hits) > 0, hits
+}
+
+// eventPart 按 part 名称从响应数据中取出匹配内容（默认 body）
+// 除 body / header / all / raw / status_code / content_length 外，
+// 其他名称按响应头查找（如 content_type、x_powered_by）
+func eventPart(event map[string]interface{}, part string) string {
+	if part == "" {
+		part = "body"
+	}
+	if part == "response" {
+		part = "raw"
+	}
+	v, ok := event[part]
+	if !ok || v == nil {
+		if headers, ok := event["headers"].(map[string]string); ok {
+			return headers[normalizeKVKey(part)]
+		}
+		return ""
+	}
+	if str, ok := v.(string); ok {
+		return str
+	}
+	return fmt.Sprint(v)
+}
+
+// eventInt 从响应数据中读取整数字段
+func eventInt(event map[string]interface{}, key string) int {
+	switch v := event[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
 }
 
 func formatHeaders(headers http.Header) string {
@@ -1253,33 +2060,48 @@ func formatHeaders(headers http.Header) string {
 // regexCache 缓存已编译的正则表达式，避免重复编译
 var regexCache = sync.Map{}
 
-func matchRegex(content, pattern string) bool {
+// findRegex 返回正则命中的内容（all 为 true 时返回全部命中，否则只返回第一个）
+func findRegex(content, pattern string, all bool) []string {
 	if pattern == "" {
-		return false
+		return nil
 	}
 
-	// 尝试从缓存获取
-	if cached, ok := regexCache.Load(pattern); ok {
-		return cached.(*regexp.Regexp).MatchString(content)
+	re, err := compileRegexCached(pattern)
+	if err != nil {
+		// 如果正则编译失败，回退到子串匹配（兼容含特殊字符的简单模式）
+		if strings.Contains(content, pattern) {
+			return []string{pattern}
+		}
+		return nil
+	}
+	if all {
+		return re.FindAllString(content, -1)
 	}
+	if loc := re.FindStringIndex(content); loc != nil {
+		return []string{content[loc[0]:loc[1]]}
+	}
+	return nil
+}
 
-	// 编译并缓存
+// compileRegexCached 编译正则并缓存
+func compileRegexCached(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := regexCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
 	re, err := regexp.Compile(pattern)
 	if err != nil {
-		// 如果正则编译失败，回退到子串匹配（兼容含特殊字符的简单模式）
-		return strings.Contains(content, pattern)
+		return nil, err
 	}
 	regexCache.Store(pattern, re)
-	return re.MatchString(content)
+	return re, nil
 }
 
-// StopScan 停止扫描
+// StopScan 停止扫描（排队中的扫描直接移出队列）
 func (s *Scanner) StopScan(scanID string) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	job, ok := s.scans[scanID]
 	if !ok {
+		s.mu.Unlock()
 		return fmt.Errorf("扫描任务不存在: %s", scanID)
 	}
 
@@ -1289,7 +2111,97 @@ func (s *Scanner) StopScan(scanID string) error {
 
 	job.Status.Status = "stopped"
 	job.Status.CompletedAt = time.Now()
+	queued := s.removeQueuedLocked(job)
+	if queued {
+		job.Status.Resumable = job.Checkpoint != nil
+	}
+	s.mu.Unlock()
+
+	// 运行中的扫描结束时会保存，排队中的扫描没有执行，在这里保存
+	if queued {
+		s.saveScanToDisk(scanID)
+	}
+	return nil
+}
+
+// PauseScan 暂停扫描：取消正在执行的任务，已完成的任务记录在断点中，之后可用 ResumeScan 继续
+// 排队中的扫描移出队列
+func (s *Scanner) PauseScan(scanID string) error {
+	s.mu.Lock()
+	job, ok := s.scans[scanID]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("扫描任务不存在: %s", scanID)
+	}
+	if (job.Status.Status != "running" && job.Status.Status != "queued") || job.Cancel == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("扫描未在运行: %s", scanID)
+	}
+
+	job.Status.Status = "paused"
+	job.Cancel()
+	queued := s.removeQueuedLocked(job)
+	if queued {
+		job.Status.Resumable = job.Checkpoint != nil
+	}
+	s.mu.Unlock()
+
+	if queued {
+		s.saveScanToDisk(scanID)
+	}
+	return nil
+}
+
+// ResumeScan 从断点继续暂停、停止或中断的扫描，已完成的 (目标, 模板) 组合不会重复执行
+// 继续的扫描按原优先级重新排队
+// templates / workflows 为按原扫描的 TemplateIDs / WorkflowIDs 重新加载的模板
+func (s *Scanner) ResumeScan(ctx context.Context, scanID string, templates []models.POCTemplate, workflows []models.Workflow, templatesDir string) error {
+	s.mu.RLock()
+	old, ok := s.scans[scanID]
+	var targets []string
+	var targetsFile string
+	if ok {
+		targets, targetsFile = old.Status.Targets, old.Status.TargetsFile
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("扫描任务不存在: %s", scanID)
+	}
+
+	targetCount, err := countTargets(targets, targetsFile)
+	if err != nil {
+		return err
+	}
 
+	s.mu.Lock()
+	if s.scans[scanID] != old {
+		s.mu.Unlock()
+		return fmt.Errorf("扫描任务已变化: %s", scanID)
+	}
+	if old.Status.Status == "running" || old.Status.Status == "queued" {
+		s.mu.Unlock()
+		return fmt.Errorf("扫描正在运行: %s", scanID)
+	}
+	if old.Checkpoint == nil || !old.Status.Resumable {
+		s.mu.Unlock()
+		return fmt.Errorf("扫描没有可继续的断点: %s", scanID)
+	}
+
+	job, perTarget, saved := newScanJob(scanID, targets, targetsFile, templates, workflows, templatesDir, old.Checkpoint.Options)
+	job.Checkpoint = old.Checkpoint
+	job.Status = old.Status
+	job.Status.Resumable = false
+	job.Status.Error = ""
+	job.Status.CompletedAt = time.Time{}
+	job.Status.TargetCount = targetCount
+	job.Status.Total = targetCount * perTarget
+	job.Status.RequestsSaved = targetCount * saved
+
+	s.scans[scanID] = job
+	s.queueJobLocked(ctx, job)
+	s.mu.Unlock()
+
+	s.saveScanToDisk(scanID)
 	return nil
 }
 
@@ -1332,18 +2244,27 @@ func (s *Scanner) GetAllScans() ([]models.ScanStatus, error) {
 	return scans, nil
 }
 
-// Stop 停止所有扫描
+// Stop 停止所有扫描（应用退出时调用）
+// 运行中和排队中的扫描标记为暂停并立即保存断点，下次启动后可以继续
 func (s *Scanner) Stop() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	for _, job := range s.scans {
-		if job.Cancel != nil && job.Status.Status == "running" {
+	var paused []string
+	for id, job := range s.scans {
+		if job.Cancel != nil && (job.Status.Status == "running" || job.Status.Status == "queued") {
 			job.Cancel()
-			job.Status.Status = "stopped"
+			job.Status.Status = "paused"
+			job.Status.Resumable = job.Checkpoint != nil
+			job.Status.QueuePosition = 0
 			job.Status.CompletedAt = time.Now()
+			paused = append(paused, id)
 		}
 	}
+	s.queue = nil
+	s.mu.Unlock()
+
+	for _, id := range paused {
+		s.saveScanToDisk(id)
+	}
 }
 
 // GetTemplateFilePath 获取模板文件路径
@@ -1382,40 +2303,36 @@ func (s *Scanner) DeleteScan(scanID string) error {
 	if job.Cancel != nil && job.Status.Status == "running" {
 		job.Cancel()
 	}
+	s.removeQueuedLocked(job)
 
 	delete(s.scans, scanID)
 	delete(s.results, scanID)
+	delete(s.checked, scanID)
 
 	// 删除磁盘文件
 	if s.scansDir != "" {
-		filePath := filepath.Join(s.scansDir, scanID+".json")
-		os.Remove(filePath)
+		os.Remove(s.scanFilePath(scanID))
+		os.Remove(s.resultLogPath(scanID))
 	}
 	return nil
 }
 
-// ExportScanResults 导出扫描结果为 JSON
-func (s *Scanner) ExportScanResults(scanID string) (string, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	results, ok := s.results[scanID]
-	if !ok {
-		return "", fmt.Errorf("扫描任务不存在: %s", scanID)
-	}
+// exportResult 导出的单条发现
+type exportResult struct {
+	TemplateName string `json:"templateName"`
+	Severity     string `json:"severity"`
+	Host         string `json:"host"`
+	Matched      string `json:"matched"`
+	Request      string `json:"request,omitempty"`
+	Response     string `json:"response,omitempty"`
+}
 
-	type ExportResult struct {
-		TemplateName string `json:"templateName"`
-		Severity     string `json:"severity"`
-		Host         string `json:"host"`
-		Matched      string `json:"matched"`
-		Request      string `json:"request,omitempty"`
-		Response     string `json:"response,omitempty"`
-	}
-	exports := make([]ExportResult, 0, len(results))
+// toExportResults 转换为导出格式（只导出命中的结果）
+func toExportResults(results []models.ScanResult) []exportResult {
+	exports := make([]exportResult, 0, len(results))
 	for _, r := range results {
 		if r.Matched != "" {
-			exports = append(exports, ExportResult{
+			exports = append(exports, exportResult{
 				TemplateName: r.TemplateName,
 				Severity:     r.Severity,
 				Host:         r.Host,
@@ -1425,6 +2342,20 @@ func (s *Scanner) ExportScanResults(scanID string) (string, error) {
 			})
 		}
 	}
+	return exports
+}
+
+// ExportScanResults 导出扫描结果为 JSON
+func (s *Scanner) ExportScanResults(scanID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results, ok := s.results[scanID]
+	if !ok {
+		return "", fmt.Errorf("扫描任务不存在: %s", scanID)
+	}
+
+	exports := toExportResults(results)
 
 	data, err := json.MarshalIndent(exports, "", "  ")
 	if err != nil {
@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+func TestScanKeepsOneErrorRowPerTarget(t *testing.T) {
+	// 已关闭的服务：每个模板都连接失败
+	var targets []string
+	for i := 0; i < 2; i++ {
+		srv := httptest.NewServer(nil)
+		targets = append(targets, srv.URL)
+		srv.Close()
+	}
+	var templates []models.POCTemplate
+	for i := 0; i < 3; i++ {
+		templates = append(templates, wordTemplate(fmt.Sprintf("t%d", i), fmt.Sprintf("/%d", i), "x"))
+	}
+
+	dir := t.TempDir()
+	s := NewScanner(dir)
+	id, err := s.Start(context.Background(), targets, "", templates, nil, "", models.ScanOptions{AllowPrivate: true, Timeout: 2, Concurrency: 3}, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := waitScan(t, s, id)
+
+	check := func(s *Scanner, status models.ScanStatus) {
+		t.Helper()
+		if status.Errors != 6 {
+			t.Errorf("errors = %d, want 6", status.Errors)
+		}
+		results, err := s.GetResults(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hosts := make(map[string]int)
+		for _, r := range results {
+			if r.Error == "" {
+				t.Errorf("unexpected result: %+v", r)
+			}
+			hosts[r.Host]++
+		}
+		if len(results) != 2 || len(hosts) != 2 {
+			t.Errorf("results = %d on %d hosts, want one error row per target", len(results), len(hosts))
+		}
+	}
+	check(s, status)
+
+	// 重新加载后按结果日志重建计数
+	reloaded := NewScanner(dir)
+	loaded, err := reloaded.GetStatus(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(reloaded, *loaded)
+}
@@ -0,0 +1,439 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tlsVersions 模板中的 TLS 版本名称（crypto/tls 不支持 SSLv3，版本枚举时由 probeSSL30 单独探测）
+var tlsVersions = []struct {
+	name    string
+	version uint16
+}{
+	{"tls10", tls.VersionTLS10},
+	{"tls11", tls.VersionTLS11},
+	{"tls12", tls.VersionTLS12},
+	{"tls13", tls.VersionTLS13},
+}
+
+// SSLRequest SSL/TLS 检测请求配置
+type SSLRequest struct {
+	Address           string // 连接地址（默认 {{Host}}:{{Port}}，未显式指定端口时为 443）
+	ServerName        string // SNI（默认为地址中的域名）
+	MinVersion        string // tls10, tls11, tls12, tls13
+	MaxVersion        string
+	CipherSuites      []string // 只提供这些套件（用于探测弱套件）
+	VersionEnum       bool     // 逐个版本握手，列出支持的版本
+	CipherEnum        bool     // 逐个套件握手，列出支持的套件（TLS 1.0 - 1.2，含 crypto/tls 不支持的弱套件）
+	Matchers          []Matcher
+	MatchersCondition string
+	Extractors        []Extractor
+}
+
+// nucleiSSLReq Nuclei SSL 请求结构
+type nucleiSSLReq struct {
+	Address           string            `yaml:"address"`
+	ServerName        string            `yaml:"server_name"`
+	MinVersion        string            `yaml:"min_version"`
+	MaxVersion        string            `yaml:"max_version"`
+	CipherSuites      []string          `yaml:"cipher_suites"`
+	VersionEnum       bool              `yaml:"tls_version_enum"`
+	CipherEnum        bool              `yaml:"tls_cipher_enum"`
+	MatchersCondition string            `yaml:"matchers-condition"`
+	Matchers          []nucleiMatcher   `yaml:"matchers"`
+	Extractors        []nucleiExtractor `yaml:"extractors"`
+}
+
+// sslResponse 一次 TLS 握手的检测结果（序列化为 JSON 作为响应，字段同时可在 dsl 中直接引用）
+type sslResponse struct {
+	Host              string    `json:"host"`
+	Port              string    `json:"port"`
+	IP                string    `json:"ip"`
+	SNI               string    `json:"sni,omitempty"`
+	TLSVersion        string    `json:"tls_version"`
+	Cipher            string    `json:"cipher"`
+	WeakCipher        bool      `json:"weak_cipher"`
+	DeprecatedVersion bool      `json:"deprecated_version"`
+	NotBefore         time.Time `json:"not_before"`
+	NotAfter          time.Time `json:"not_after"`
+	Expired           bool      `json:"expired"`
+	SelfSigned        bool      `json:"self_signed"`
+	Mismatched        bool      `json:"mismatched"`
+	Untrusted         bool      `json:"untrusted"`
+	Wildcard          bool      `json:"wildcard_certificate"`
+	SubjectDN         string    `json:"subject_dn"`
+	SubjectCN         string    `json:"subject_cn"`
+	SubjectOrg        []string  `json:"subject_org,omitempty"`
+	SubjectAN         []string  `json:"subject_an,omitempty"`
+	IssuerDN          string    `json:"issuer_dn"`
+	IssuerCN          string    `json:"issuer_cn"`
+	IssuerOrg         []string  `json:"issuer_org,omitempty"`
+	Serial            string    `json:"serial"`
+	FingerprintSHA1   string    `json:"fingerprint_sha1"`
+	FingerprintSHA256 string    `json:"fingerprint_sha256"`
+	Chain             []string  `json:"chain"`
+	VersionEnum       []string  `json:"tls_version_enum,omitempty"`
+	CipherEnum        []string  `json:"tls_cipher_enum,omitempty"`
+	WeakCiphers       []string  `json:"weak_ciphers,omitempty"`
+	Untested          []string  `json:"untested,omitempty"` // 探测未能完成（超时、连接失败）的版本和套件，不代表不支持
+}
+
+// parseSSLRequests 解析模板中的 ssl 块
+func parseSSLRequests(content string) ([]SSLRequest, error) {
+	var nt nucleiYAML
+	if err := yaml.Unmarshal([]byte(content), &nt); err != nil {
+		return nil, fmt.Errorf("YAML 解析失败: %w", err)
+	}
+
+	var requests []SSLRequest
+	for _, ns := range nt.SSL {
+		req := SSLRequest{
+			Address:           ns.Address,
+			ServerName:        ns.ServerName,
+			MinVersion:        strings.ToLower(ns.MinVersion),
+			MaxVersion:        strings.ToLower(ns.MaxVersion),
+			CipherSuites:      ns.CipherSuites,
+			VersionEnum:       ns.VersionEnum,
+			CipherEnum:        ns.CipherEnum,
+			MatchersCondition: ns.MatchersCondition,
+			Matchers:          convertMatchers(ns.Matchers),
+			Extractors:        convertExtractors(ns.Extractors),
+		}
+		if req.Address == "" {
+			req.Address = "{{Host}}:{{Port}}"
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// executeSSLTemplate 依次执行模板中的 SSL 请求
+// 结果的主机统一为目标 BaseURL（与未命中、失败结果一致，对比扫描按此匹配），握手地址记录在请求中
+func (s *Scanner) executeSSLTemplate(ctx context.Context, job *ScanJob, info *targetInfo, template models.POCTemplate, requests []SSLRequest) []*models.ScanResult {
+	host := info.BaseURL
+	tctx, err := s.newProtocolContext(job, info, template, len(requests))
+	if err != nil {
+		return []*models.ScanResult{newErrorResult(template, host, err.Error())}
+	}
+	// 输入未显式指定端口时（如 example.com）检测 443 而不是 http 的 80
+	if !strings.Contains(info.Hostname, ":") || strings.HasSuffix(info.Hostname, "]") {
+		tctx.vars["Port"] = "443"
+	}
+
+	var results []*models.ScanResult
+	var lastErr error
+	for _, req := range requests {
+		if ctx.Err() != nil {
+			break
+		}
+		addr, err := expandVariables(req.Address, tctx.variables())
+		if err != nil {
+			results = append(results, newErrorResult(template, host, err.Error()))
+			return results
+		}
+		addr = withDefaultPort(addr, "443")
+
+		ex, err := inspectTLS(ctx, jobLimiter(job), req, addr, jobTimeout(job))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if r := s.matchProtocolEvent(tctx, template, host, req.Matchers, req.MatchersCondition, req.Extractors, ex.event, ex.request, ex.response); r != nil {
+			results = append(results, r)
+		}
+	}
+	results = append(results, s.resolveOASTChecks(ctx, tctx)...)
+	return finishResults(results, template, host, lastErr)
+}
+
+// inspectTLS 握手并收集证书、版本、套件信息，按需枚举支持的版本和套件
+func inspectTLS(ctx context.Context, limiter *rateLimiter, req SSLRequest, addr string, timeout time.Duration) (*httpExchange, error) {
+	hostname, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的地址 %s: %v", addr, err)
+	}
+	cfg, err := sslConfig(req, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	state, remote, err := tlsHandshake(ctx, limiter, addr, cfg, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("服务端未返回证书")
+	}
+
+	resp := describeTLS(state, hostname, cfg.ServerName)
+	resp.Host, resp.Port, resp.IP = hostname, port, remote
+
+	if req.VersionEnum {
+		// SSLv3 用手工构造的 ClientHello 探测，无法得出结论时记入 untested 而不是视为不支持
+		if ok, err := probeSSL30(ctx, limiter, addr, cfg.ServerName, timeout); err != nil {
+			resp.Untested = append(resp.Untested, "ssl30")
+		} else if ok {
+			resp.VersionEnum = append(resp.VersionEnum, "ssl30")
+		}
+		for _, v := range tlsVersions {
+			probe := cfg.Clone()
+			probe.MinVersion, probe.MaxVersion, probe.CipherSuites = v.version, v.version, nil
+			if _, _, err := tlsHandshake(ctx, limiter, addr, probe, timeout); err == nil {
+				resp.VersionEnum = append(resp.VersionEnum, v.name)
+			}
+		}
+	}
+	if req.CipherEnum {
+		insecure := insecureCipherIDs()
+		for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			if !supportsPreTLS13(suite) {
+				continue
+			}
+			probe := cfg.Clone()
+			probe.MinVersion, probe.MaxVersion = tls.VersionTLS10, tls.VersionTLS12
+			probe.CipherSuites = []uint16{suite.ID}
+			if _, _, err := tlsHandshake(ctx, limiter, addr, probe, timeout); err != nil {
+				continue
+			}
+			resp.CipherEnum = append(resp.CipherEnum, suite.Name)
+			if insecure[suite.ID] {
+				resp.WeakCiphers = append(resp.WeakCiphers, suite.Name)
+			}
+		}
+		// crypto/tls 无法协商的 NULL、EXPORT、单 DES 等套件
+		for _, suite := range legacyCipherSuites {
+			ok, err := probeLegacyCipher(ctx, limiter, addr, suite.id, cfg.ServerName, timeout)
+			if err != nil {
+				resp.Untested = append(resp.Untested, suite.name)
+				continue
+			}
+			if ok {
+				resp.CipherEnum = append(resp.CipherEnum, suite.name)
+				resp.WeakCiphers = append(resp.WeakCiphers, suite.name)
+			}
+		}
+	}
+
+	data, _ := json.MarshalIndent(resp, "", "  ")
+	event := sslEvent(resp)
+	event["raw"] = string(data)
+	event["body"] = string(data)
+	event["duration"] = time.Since(start).Seconds()
+	return &httpExchange{
+		event:    event,
+		request:  fmt.Sprintf("%s\nSNI: %s\n版本: %s - %s", addr, cfg.ServerName, tlsVersionName(cfg.MinVersion), tlsVersionName(cfg.MaxVersion)),
+		response: string(data),
+	}, nil
+}
+
+// sslConfig 根据请求生成握手配置（检测场景不校验证书，信任状态单独计算）
+func sslConfig(req SSLRequest, hostname string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         req.ServerName,
+		MinVersion:         tls.VersionTLS10,
+		MaxVersion:         tls.VersionTLS13,
+	}
+	if cfg.ServerName == "" && net.ParseIP(hostname) == nil {
+		cfg.ServerName = hostname
+	}
+	for _, v := range []struct {
+		name string
+		dst  *uint16
+	}{{req.MinVersion, &cfg.MinVersion}, {req.MaxVersion, &cfg.MaxVersion}} {
+		if v.name == "" {
+			continue
+		}
+		version, ok := parseTLSVersion(v.name)
+		if !ok {
+			return nil, fmt.Errorf("不支持的 TLS 版本: %s", v.name)
+		}
+		*v.dst = version
+	}
+	for _, name := range req.CipherSuites {
+		id, ok := cipherSuiteID(name)
+		if !ok {
+			return nil, fmt.Errorf("不支持的加密套件: %s", name)
+		}
+		cfg.CipherSuites = append(cfg.CipherSuites, id)
+	}
+	return cfg, nil
+}
+
+// tlsHandshake 建立连接并完成一次握手，返回连接状态和对端 IP（连接前按扫描的速率限制等待）
+func tlsHandshake(ctx context.Context, limiter *rateLimiter, addr string, cfg *tls.Config, timeout time.Duration) (tls.ConnectionState, string, error) {
+	if err := limiter.wait(ctx); err != nil {
+		return tls.ConnectionState{}, "", err
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return tls.ConnectionState{}, "", fmt.Errorf("连接失败: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return tls.ConnectionState{}, "", fmt.Errorf("TLS 握手失败: %v", err)
+	}
+	remote, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	return tlsConn.ConnectionState(), remote, nil
+}
+
+// describeTLS 汇总握手状态和叶子证书信息
+func describeTLS(state tls.ConnectionState, hostname, sni string) *sslResponse {
+	leaf := state.PeerCertificates[0]
+	sha1Sum := sha1.Sum(leaf.Raw)
+	sha256Sum := sha256.Sum256(leaf.Raw)
+	now := time.Now()
+
+	resp := &sslResponse{
+		SNI:               sni,
+		TLSVersion:        tlsVersionName(state.Version),
+		Cipher:            tls.CipherSuiteName(state.CipherSuite),
+		WeakCipher:        insecureCipherIDs()[state.CipherSuite],
+		DeprecatedVersion: state.Version < tls.VersionTLS12,
+		NotBefore:         leaf.NotBefore,
+		NotAfter:          leaf.NotAfter,
+		Expired:           now.After(leaf.NotAfter) || now.Before(leaf.NotBefore),
+		SelfSigned:        isSelfSigned(leaf),
+		Mismatched:        leaf.VerifyHostname(hostname) != nil,
+		SubjectDN:         leaf.Subject.String(),
+		SubjectCN:         leaf.Subject.CommonName,
+		SubjectOrg:        leaf.Subject.Organization,
+		SubjectAN:         leaf.DNSNames,
+		IssuerDN:          leaf.Issuer.String(),
+		IssuerCN:          leaf.Issuer.CommonName,
+		IssuerOrg:         leaf.Issuer.Organization,
+		Serial:            leaf.SerialNumber.String(),
+		FingerprintSHA1:   hex.EncodeToString(sha1Sum[:]),
+		FingerprintSHA256: hex.EncodeToString(sha256Sum[:]),
+	}
+	for _, ip := range leaf.IPAddresses {
+		resp.SubjectAN = append(resp.SubjectAN, ip.String())
+	}
+	for _, name := range append([]string{leaf.Subject.CommonName}, leaf.DNSNames...) {
+		if strings.HasPrefix(name, "*.") {
+			resp.Wildcard = true
+		}
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range state.PeerCertificates {
+		resp.Chain = append(resp.Chain, c.Subject.String())
+		if c != leaf {
+			intermediates.AddCert(c)
+		}
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{Intermediates: intermediates})
+	resp.Untrusted = err != nil
+	return resp
+}
+
+// sslEvent 将检测结果展开为 matcher / dsl 可直接引用的字段（时间为 Unix 秒）
+func sslEvent(r *sslResponse) map[string]interface{} {
+	return map[string]interface{}{
+		"host":                 r.Host,
+		"port":                 r.Port,
+		"ip":                   r.IP,
+		"sni":                  r.SNI,
+		"tls_version":          r.TLSVersion,
+		"cipher":               r.Cipher,
+		"weak_cipher":          r.WeakCipher,
+		"deprecated_version":   r.DeprecatedVersion,
+		"not_before":           r.NotBefore.Unix(),
+		"not_after":            r.NotAfter.Unix(),
+		"expired":              r.Expired,
+		"self_signed":          r.SelfSigned,
+		"mismatched":           r.Mismatched,
+		"untrusted":            r.Untrusted,
+		"wildcard_certificate": r.Wildcard,
+		"subject_dn":           r.SubjectDN,
+		"subject_cn":           r.SubjectCN,
+		"subject_org":          r.SubjectOrg,
+		"subject_an":           r.SubjectAN,
+		"issuer_dn":            r.IssuerDN,
+		"issuer_cn":            r.IssuerCN,
+		"issuer_org":           r.IssuerOrg,
+		"serial":               r.Serial,
+		"fingerprint_sha1":     r.FingerprintSHA1,
+		"fingerprint_sha256":   r.FingerprintSHA256,
+		"chain":                r.Chain,
+		"tls_version_enum":     r.VersionEnum,
+		"tls_cipher_enum":      r.CipherEnum,
+		"weak_ciphers":         r.WeakCiphers,
+		"untested":             r.Untested,
+	}
+}
+
+// isSelfSigned 证书的签发者与主体相同且能用自身公钥验证签名
+func isSelfSigned(cert *x509.Certificate) bool {
+	if cert.Subject.String() != cert.Issuer.String() {
+		return false
+	}
+	return cert.CheckSignatureFrom(cert) == nil
+}
+
+// parseTLSVersion 解析模板中的版本名称
+func parseTLSVersion(name string) (uint16, bool) {
+	for _, v := range tlsVersions {
+		if v.name == name {
+			return v.version, true
+		}
+	}
+	return 0, false
+}
+
+// tlsVersionName 版本号转换为模板中的名称
+func tlsVersionName(version uint16) string {
+	for _, v := range tlsVersions {
+		if v.version == version {
+			return v.name
+		}
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+// cipherSuiteID 按 IANA 名称查找加密套件（含不安全套件）
+func cipherSuiteID(name string) (uint16, bool) {
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if strings.EqualFold(suite.Name, name) {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
+
+// insecureCipherIDs crypto/tls 认定为不安全的套件
+func insecureCipherIDs() map[uint16]bool {
+	ids := make(map[uint16]bool)
+	for _, suite := range tls.InsecureCipherSuites() {
+		ids[suite.ID] = true
+	}
+	return ids
+}
+
+// supportsPreTLS13 套件可用于 TLS 1.2 及以下（TLS 1.3 套件不可配置）
+func supportsPreTLS13(suite *tls.CipherSuite) bool {
+	for _, v := range suite.SupportedVersions {
+		if v < tls.VersionTLS13 {
+			return true
+		}
+	}
+	return false
+}
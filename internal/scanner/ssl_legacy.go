@@ -0,0 +1,172 @@
+package scanner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// versionSSL30 SSLv3 的协议版本号（crypto/tls 无法协商）
+const versionSSL30 = 0x0300
+
+// legacyCipherSuites crypto/tls 无法协商的弱套件（NULL、EXPORT、单 DES、RC4-MD5、匿名 DH），
+// 通过手工构造的 ClientHello 逐个探测
+var legacyCipherSuites = []struct {
+	id   uint16
+	name string
+}{
+	{0x0001, "TLS_RSA_WITH_NULL_MD5"},
+	{0x0002, "TLS_RSA_WITH_NULL_SHA"},
+	{0x003B, "TLS_RSA_WITH_NULL_SHA256"},
+	{0x0003, "TLS_RSA_EXPORT_WITH_RC4_40_MD5"},
+	{0x0006, "TLS_RSA_EXPORT_WITH_RC2_CBC_40_MD5"},
+	{0x0008, "TLS_RSA_EXPORT_WITH_DES40_CBC_SHA"},
+	{0x0014, "TLS_DHE_RSA_EXPORT_WITH_DES40_CBC_SHA"},
+	{0x0009, "TLS_RSA_WITH_DES_CBC_SHA"},
+	{0x0015, "TLS_DHE_RSA_WITH_DES_CBC_SHA"},
+	{0x0004, "TLS_RSA_WITH_RC4_128_MD5"},
+	{0x0018, "TLS_DH_anon_WITH_RC4_128_MD5"},
+	{0x001A, "TLS_DH_anon_WITH_DES_CBC_SHA"},
+	{0x0034, "TLS_DH_anon_WITH_AES_128_CBC_SHA"},
+}
+
+// ssl30CipherSuites 探测 SSLv3 时提供的套件：SSLv3 时代的常见套件加上全部弱套件
+var ssl30CipherSuites = func() []uint16 {
+	ids := []uint16{0x0005, 0x000A, 0x0016, 0x002F, 0x0033, 0x0035, 0x0039}
+	for _, s := range legacyCipherSuites {
+		ids = append(ids, s.id)
+	}
+	return ids
+}()
+
+// errLegacyRejected 服务端拒绝了探测的版本或套件（返回告警或直接关闭连接）
+var errLegacyRejected = errors.New("服务端拒绝")
+
+// probeLegacyHello 发送只包含 suites 的 ClientHello，返回服务端 ServerHello 中的版本和套件
+// 服务端返回告警或关闭连接时返回 errLegacyRejected；连接失败、超时等无法判断的情况返回其他错误
+func probeLegacyHello(ctx context.Context, limiter *rateLimiter, addr string, version uint16, suites []uint16, serverName string, timeout time.Duration) (uint16, uint16, error) {
+	if err := limiter.wait(ctx); err != nil {
+		return 0, 0, err
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("连接失败: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	hello, err := legacyClientHello(version, suites, serverName)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := conn.Write(hello); err != nil {
+		return 0, 0, fmt.Errorf("发送失败: %v", err)
+	}
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() {
+			return 0, 0, fmt.Errorf("读取响应超时: %v", err)
+		}
+		// 不支持旧版本的服务端通常直接关闭或重置连接
+		return 0, 0, errLegacyRejected
+	}
+	switch header[0] {
+	case 0x15: // alert
+		return 0, 0, errLegacyRejected
+	case 0x16: // handshake
+	default:
+		return 0, 0, fmt.Errorf("无法识别的响应记录类型: 0x%02x", header[0])
+	}
+	record := make([]byte, binary.BigEndian.Uint16(header[3:5]))
+	if _, err := io.ReadFull(conn, record); err != nil {
+		return 0, 0, fmt.Errorf("读取响应失败: %v", err)
+	}
+	return parseServerHello(record)
+}
+
+// legacyClientHello 构造 ClientHello 记录（SSLv3 不带扩展，TLS 带 SNI）
+func legacyClientHello(version uint16, suites []uint16, serverName string) ([]byte, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	body := binary.BigEndian.AppendUint16(nil, version)
+	body = append(body, random...)
+	body = append(body, 0) // session_id
+	body = binary.BigEndian.AppendUint16(body, uint16(len(suites)*2))
+	for _, id := range suites {
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	body = append(body, 1, 0) // compression_methods: null
+
+	if version > versionSSL30 && serverName != "" {
+		name := []byte(serverName)
+		list := append([]byte{0}, binary.BigEndian.AppendUint16(nil, uint16(len(name)))...)
+		list = append(list, name...)
+		sni := binary.BigEndian.AppendUint16(nil, uint16(len(list)))
+		sni = append(sni, list...)
+		ext := binary.BigEndian.AppendUint16(nil, 0) // server_name
+		ext = binary.BigEndian.AppendUint16(ext, uint16(len(sni)))
+		ext = append(ext, sni...)
+		body = binary.BigEndian.AppendUint16(body, uint16(len(ext)))
+		body = append(body, ext...)
+	}
+
+	hs := []byte{1, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))} // client_hello
+	hs = append(hs, body...)
+	// 记录层版本不超过 TLS 1.0，兼容只接受旧记录版本的服务端
+	recordVersion := version
+	if recordVersion > 0x0301 {
+		recordVersion = 0x0301
+	}
+	record := []byte{0x16}
+	record = binary.BigEndian.AppendUint16(record, recordVersion)
+	record = binary.BigEndian.AppendUint16(record, uint16(len(hs)))
+	return append(record, hs...), nil
+}
+
+// parseServerHello 从握手记录中读取 ServerHello 的版本和套件
+func parseServerHello(record []byte) (uint16, uint16, error) {
+	// type(1) length(3) version(2) random(32) session_id_len(1)
+	if len(record) < 39 || record[0] != 2 {
+		return 0, 0, fmt.Errorf("无效的 ServerHello")
+	}
+	version := binary.BigEndian.Uint16(record[4:6])
+	offset := 38 + 1 + int(record[38])
+	if len(record) < offset+2 {
+		return 0, 0, fmt.Errorf("无效的 ServerHello")
+	}
+	return version, binary.BigEndian.Uint16(record[offset : offset+2]), nil
+}
+
+// probeSSL30 探测服务端是否支持 SSLv3
+func probeSSL30(ctx context.Context, limiter *rateLimiter, addr, serverName string, timeout time.Duration) (bool, error) {
+	version, _, err := probeLegacyHello(ctx, limiter, addr, versionSSL30, ssl30CipherSuites, serverName, timeout)
+	if errors.Is(err, errLegacyRejected) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return version == versionSSL30, nil
+}
+
+// probeLegacyCipher 探测服务端是否接受单个弱套件（TLS 1.0 - 1.2）
+func probeLegacyCipher(ctx context.Context, limiter *rateLimiter, addr string, id uint16, serverName string, timeout time.Duration) (bool, error) {
+	_, chosen, err := probeLegacyHello(ctx, limiter, addr, 0x0303, []uint16{id}, serverName, timeout)
+	if errors.Is(err, errLegacyRejected) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return chosen == id, nil
+}
@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// targetInfo 解析后的扫描目标（每个目标只解析一次）
+// 以 https://a.com:8443/app/index.php?x=1 为例：
+//
+//	BaseURL  https://a.com:8443/app/index.php?x=1
+//	RootURL  https://a.com:8443
+//	Hostname a.com:8443
+//	Host     a.com
+//	Port     8443
+//	Path     /app
+//	File     index.php
+//	Scheme   https
+type targetInfo struct {
+	Input    string // 原始输入
+	BaseURL  string
+	RootURL  string
+	Hostname string // 带端口（仅当输入中显式指定端口时）
+	Host     string
+	Port     string // 未指定时为 scheme 默认端口
+	Path     string // 目录部分（不含文件名）
+	File     string // 文件名部分（输入以 / 结尾时为空）
+	Scheme   string
+
+	LocalPath string // 本地目录、文件或压缩包（file 模板的目标）
+
+	basePath string     // 目标路径（去掉结尾 /）
+	query    url.Values // 目标自带的查询参数，拼接请求时合并
+}
+
+// parseTarget 解析扫描目标（无 scheme 时默认 http）
+func parseTarget(raw string) (*targetInfo, error) {
+	input := strings.TrimSpace(raw)
+	if input == "" {
+		return nil, fmt.Errorf("目标为空")
+	}
+	if p, ok := localTargetPath(input); ok {
+		return &targetInfo{
+			Input:     input,
+			BaseURL:   p,
+			Hostname:  p,
+			Host:      p,
+			Path:      filepath.Dir(p),
+			File:      filepath.Base(p),
+			LocalPath: p,
+		}, nil
+	}
+	u, err := url.Parse(normalizeTarget(input))
+	if err != nil {
+		return nil, fmt.Errorf("无法解析目标 URL: %v", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("目标缺少主机名: %s", input)
+	}
+
+	t := &targetInfo{
+		Input:    input,
+		Scheme:   u.Scheme,
+		Hostname: u.Host,
+		Host:     u.Hostname(),
+		Port:     u.Port(),
+		RootURL:  u.Scheme + "://" + u.Host,
+		basePath: strings.TrimSuffix(u.EscapedPath(), "/"),
+		query:    u.Query(),
+	}
+	if t.Port == "" {
+		t.Port = "80"
+		if u.Scheme == "https" {
+			t.Port = "443"
+		}
+	}
+
+	// Path / File：以 / 结尾时整个路径都是目录
+	p := u.Path
+	switch {
+	case p == "" || p == "/":
+		t.Path = "/"
+	case strings.HasSuffix(p, "/"):
+		t.Path = strings.TrimSuffix(p, "/")
+	default:
+		t.Path = path.Dir(p)
+		t.File = path.Base(p)
+	}
+
+	t.BaseURL = t.RootURL + t.basePath
+	if u.RawQuery != "" {
+		t.BaseURL += "?" + u.RawQuery
+	}
+	return t, nil
+}
+
+// localTargetPath 判断目标是否为本地路径（file:// 前缀、绝对路径或 ./ ../ ~/ 开头的相对路径）
+func localTargetPath(input string) (string, bool) {
+	if strings.HasPrefix(input, "file://") {
+		return filepath.FromSlash(strings.TrimPrefix(input, "file://")), true
+	}
+	if strings.HasPrefix(input, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, input[2:]), true
+		}
+	}
+	if filepath.IsAbs(input) || strings.HasPrefix(input, "./") || strings.HasPrefix(input, "../") ||
+		strings.HasPrefix(input, `.\`) || strings.HasPrefix(input, `..\`) {
+		return filepath.Clean(input), true
+	}
+	return "", false
+}
+
+// variables 目标相关的模板变量
+func (t *targetInfo) variables() map[string]interface{} {
+	return map[string]interface{}{
+		"BaseURL":  t.BaseURL,
+		"RootURL":  t.RootURL,
+		"Hostname": t.Hostname,
+		"Host":     t.Host,
+		"Port":     t.Port,
+		"Path":     t.Path,
+		"File":     t.File,
+		"Scheme":   t.Scheme,
+		"Input":    t.Input,
+	}
+}
+
+// resolveURL 将展开后的模板路径与目标拼接为完整 URL
+//   - 绝对 URL（{{BaseURL}}/x、{{RootURL}}/x）直接使用
+//   - 相对路径（/x、x）拼接在目标路径之后
+//
+// 目标自带的查询参数会合并到最终 URL 中，而不是被夹在路径中间
+func (t *targetInfo) resolveURL(p string) string {
+	var base, rest string
+	switch {
+	case len(t.query) > 0 && strings.HasPrefix(p, t.BaseURL):
+		// {{BaseURL}} 带查询参数：把模板追加的路径放回查询参数之前
+		base, rest = t.RootURL+t.basePath, strings.TrimPrefix(p, t.BaseURL)
+	case strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://"):
+		return p
+	default:
+		base, rest = t.RootURL+t.basePath, p
+	}
+
+	if rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "?") {
+		rest = "/" + rest
+	}
+	full := base + rest
+	if tail := strings.TrimPrefix(full, t.RootURL); tail == "" || strings.HasPrefix(tail, "?") {
+		// 根路径不能省略 /（http://a.com?x=1 → http://a.com/?x=1）
+		full = t.RootURL + "/" + tail
+	}
+	if len(t.query) == 0 {
+		return full
+	}
+
+	u, err := url.Parse(full)
+	if err != nil {
+		return full
+	}
+	q := u.Query()
+	for k, vs := range t.query {
+		if _, exists := q[k]; !exists {
+			q[k] = vs
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,169 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want targetInfo
+	}{
+		{
+			raw: "https://a.com:8443/app/index.php?x=1",
+			want: targetInfo{
+				BaseURL:  "https://a.com:8443/app/index.php?x=1",
+				RootURL:  "https://a.com:8443",
+				Hostname: "a.com:8443",
+				Host:     "a.com",
+				Port:     "8443",
+				Path:     "/app",
+				File:     "index.php",
+				Scheme:   "https",
+			},
+		},
+		{
+			// 以 / 结尾时整个路径都是目录
+			raw: "https://a.com:8443/app/",
+			want: targetInfo{
+				BaseURL:  "https://a.com:8443/app",
+				RootURL:  "https://a.com:8443",
+				Hostname: "a.com:8443",
+				Host:     "a.com",
+				Port:     "8443",
+				Path:     "/app",
+				File:     "",
+				Scheme:   "https",
+			},
+		},
+		{
+			raw: "https://a.com:8443/app",
+			want: targetInfo{
+				BaseURL:  "https://a.com:8443/app",
+				RootURL:  "https://a.com:8443",
+				Hostname: "a.com:8443",
+				Host:     "a.com",
+				Port:     "8443",
+				Path:     "/",
+				File:     "app",
+				Scheme:   "https",
+			},
+		},
+		{
+			// 无 scheme 默认 http，未指定端口时 Port 为默认端口，Hostname 不带端口
+			raw: "  a.com  ",
+			want: targetInfo{
+				BaseURL:  "http://a.com",
+				RootURL:  "http://a.com",
+				Hostname: "a.com",
+				Host:     "a.com",
+				Port:     "80",
+				Path:     "/",
+				Scheme:   "http",
+			},
+		},
+		{
+			raw: "https://a.com/",
+			want: targetInfo{
+				BaseURL:  "https://a.com",
+				RootURL:  "https://a.com",
+				Hostname: "a.com",
+				Host:     "a.com",
+				Port:     "443",
+				Path:     "/",
+				Scheme:   "https",
+			},
+		},
+		{
+			raw: "http://[::1]:8080/x/y/",
+			want: targetInfo{
+				BaseURL:  "http://[::1]:8080/x/y",
+				RootURL:  "http://[::1]:8080",
+				Hostname: "[::1]:8080",
+				Host:     "::1",
+				Port:     "8080",
+				Path:     "/x/y",
+				Scheme:   "http",
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := parseTarget(tt.raw)
+		if err != nil {
+			t.Errorf("parseTarget(%q) error: %v", tt.raw, err)
+			continue
+		}
+		fields := []struct {
+			name      string
+			got, want string
+		}{
+			{"BaseURL", got.BaseURL, tt.want.BaseURL},
+			{"RootURL", got.RootURL, tt.want.RootURL},
+			{"Hostname", got.Hostname, tt.want.Hostname},
+			{"Host", got.Host, tt.want.Host},
+			{"Port", got.Port, tt.want.Port},
+			{"Path", got.Path, tt.want.Path},
+			{"File", got.File, tt.want.File},
+			{"Scheme", got.Scheme, tt.want.Scheme},
+		}
+		for _, f := range fields {
+			if f.got != f.want {
+				t.Errorf("parseTarget(%q).%s = %q, want %q", tt.raw, f.name, f.got, f.want)
+			}
+		}
+	}
+}
+
+func TestParseTargetLocalAndInvalid(t *testing.T) {
+	local, err := parseTarget("./testdata/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("testdata", "app"); local.LocalPath != want || local.File != "app" {
+		t.Errorf("local target = %+v, want LocalPath %s", local, want)
+	}
+
+	for _, raw := range []string{"", "   ", "http://", "http://a.com:bad"} {
+		if _, err := parseTarget(raw); err == nil {
+			t.Errorf("parseTarget(%q) expected error", raw)
+		}
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		target string
+		path   string
+		want   string
+	}{
+		// 带端口和目录的目标
+		{"https://a.com:8443/app/", "https://a.com:8443/app/x", "https://a.com:8443/app/x"},
+		{"https://a.com:8443/app/", "/x", "https://a.com:8443/app/x"},
+		{"https://a.com:8443/app/", "x", "https://a.com:8443/app/x"},
+		{"https://a.com:8443/app/", "/x?id=1", "https://a.com:8443/app/x?id=1"},
+		{"https://a.com:8443/app/", "https://a.com:8443/root", "https://a.com:8443/root"},
+		{"https://a.com:8443/app/", "http://other.com/z", "http://other.com/z"},
+		// 根目标
+		{"a.com", "http://a.com/x", "http://a.com/x"},
+		{"a.com", "/x", "http://a.com/x"},
+		{"a.com", "", "http://a.com/"},
+		{"a.com", "?q=1", "http://a.com/?q=1"},
+		// 目标自带查询参数：合并到最终 URL，模板追加的路径放在查询参数之前
+		{"http://a.com/app/index.php?x=1", "http://a.com/app/index.php?x=1/x", "http://a.com/app/index.php/x?x=1"},
+		{"http://a.com/app/index.php?x=1", "/x", "http://a.com/app/index.php/x?x=1"},
+		{"http://a.com/app/index.php?x=1", "/x?y=2", "http://a.com/app/index.php/x?x=1&y=2"},
+		// 模板中的同名参数优先
+		{"http://a.com/?x=1", "/x?x=2", "http://a.com/x?x=2"},
+		{"http://a.com/?x=1", "", "http://a.com/?x=1"},
+	}
+	for _, tt := range tests {
+		info, err := parseTarget(tt.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.resolveURL(tt.path); got != tt.want {
+			t.Errorf("resolveURL(%q, %q) = %q, want %q", tt.target, tt.path, got, tt.want)
+		}
+	}
+}
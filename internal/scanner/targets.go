@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxTargetLineSize 目标文件中单行的最大长度
+const maxTargetLineSize = 64 * 1024
+
+// eachTarget 依次产出请求中的目标和目标文件中的目标（每行一个，忽略空行和 # 注释）
+// 文件按行流式读取，不会整体加载到内存；fn 返回 false 时停止
+func eachTarget(targets []string, file string, fn func(string) bool) error {
+	for _, t := range targets {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if !fn(t) {
+			return nil
+		}
+	}
+	if file == "" {
+		return nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("打开目标文件失败: %v", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 4096), maxTargetLineSize)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !fn(line) {
+			return nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("读取目标文件失败: %v", err)
+	}
+	return nil
+}
+
+// countTargets 统计目标总数（用于进度），同样流式读取目标文件
+func countTargets(targets []string, file string) (int, error) {
+	n := 0
+	err := eachTarget(targets, file, func(string) bool {
+		n++
+		return true
+	})
+	return n, err
+}
+
+// scanTarget 扫描中的单个目标：同一目标的所有任务共享解析结果，内网地址检查只做一次
+type scanTarget struct {
+	index int // 目标序号（断点按序号记录已完成的目标）
+	raw   string
+	info  *targetInfo
+	err   error // 目标无法解析
+
+	checkOnce sync.Once
+	checkErr  error
+}
+
+// newScanTarget 解析目标
+func newScanTarget(index int, raw string) *scanTarget {
+	info, err := parseTarget(raw)
+	return &scanTarget{index: index, raw: raw, info: info, err: err}
+}
+
+// check 检查目标是否允许扫描（本地路径目标不涉及网络）
+func (t *scanTarget) check(allowPrivate bool) error {
+	if t.err != nil {
+		return t.err
+	}
+	if allowPrivate || t.info.LocalPath != "" {
+		return nil
+	}
+	t.checkOnce.Do(func() {
+		if err := validateTarget(t.raw); err != nil {
+			t.checkErr = fmt.Errorf("目标被拒绝: %v", err)
+		}
+	})
+	return t.checkErr
+}
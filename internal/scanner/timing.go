@@ -0,0 +1,113 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SleepTimePlaceholder 请求中的延时占位符（与 nuclei time_delay analyzer 一致）
+const SleepTimePlaceholder = "[SLEEPTIME]"
+
+// 时间盲注确认的默认参数
+const (
+	DefaultSleepDuration  = 5 // 首次请求使用的延时（秒）
+	DefaultDelayRequests  = 4 // 确认阶段发送的请求数
+	delayToleranceSeconds = 1.5
+)
+
+// TimeDelay 基于响应时间的盲注确认（analyzer: time_delay）
+// 首次命中后先测量基线耗时，再以不同的延时重复发送请求，
+// 只有每次的响应耗时都与延时相符才报告结果，以排除网络抖动造成的误报
+type TimeDelay struct {
+	SleepDuration int // 首次请求的延时（秒），替换请求中的 [SLEEPTIME]
+	Requests      int // 确认阶段发送的请求数
+}
+
+// nucleiAnalyzer Nuclei analyzer 结构
+type nucleiAnalyzer struct {
+	Name       string                 `yaml:"name"`
+	Parameters map[string]interface{} `yaml:"parameters"`
+}
+
+// parseTimeDelay 解析 analyzer 块（仅支持 time_delay，其他 analyzer 忽略）
+func parseTimeDelay(a *nucleiAnalyzer) *TimeDelay {
+	if a == nil || a.Name != "time_delay" {
+		return nil
+	}
+	td := &TimeDelay{SleepDuration: DefaultSleepDuration, Requests: DefaultDelayRequests}
+	if n, err := strconv.Atoi(toDSLString(a.Parameters["sleep_duration"])); err == nil && n > 0 {
+		td.SleepDuration = n
+	}
+	if n, err := strconv.Atoi(toDSLString(a.Parameters["requests"])); err == nil && n > 0 {
+		td.Requests = n
+	}
+	return td
+}
+
+// withSleepTime 将请求中的 [SLEEPTIME] 替换为指定延时
+func withSleepTime(req HTTPRequest, input string, delay int) (HTTPRequest, string) {
+	value := strconv.Itoa(delay)
+	req.Body = strings.ReplaceAll(req.Body, SleepTimePlaceholder, value)
+	if len(req.Headers) > 0 {
+		headers := make(map[string]string, len(req.Headers))
+		for k, v := range req.Headers {
+			headers[k] = strings.ReplaceAll(v, SleepTimePlaceholder, value)
+		}
+		req.Headers = headers
+	}
+	return req, strings.ReplaceAll(input, SleepTimePlaceholder, value)
+}
+
+// confirmationDelays 确认阶段使用的延时序列：长短交替并逐渐变化（如 5,1,6,2）
+func confirmationDelays(td *TimeDelay) []int {
+	delays := make([]int, td.Requests)
+	for i := range delays {
+		if i%2 == 0 {
+			delays[i] = td.SleepDuration + i/2
+		} else {
+			delays[i] = 1 + i/2
+		}
+	}
+	return delays
+}
+
+// confirmTimeDelay 以不同延时重复发送命中的请求，确认响应耗时随延时变化
+// 每次耗时需满足：耗时 - 基线 >= 延时 * 0.9，且不超过 延时 + 基线 + 容差
+func (s *Scanner) confirmTimeDelay(ctx context.Context, tctx *templateContext, target *targetInfo, reqConfig HTTPRequest, input string, respLimit int64) (bool, string) {
+	td := reqConfig.TimeDelay
+	send := func(delay int) (float64, bool) {
+		req, in := withSleepTime(reqConfig, input, delay)
+		ex, err := s.sendRequest(ctx, jobLimiter(tctx.job), tctx.client, target, req, in, tctx.variables(), respLimit)
+		if err != nil {
+			return 0, false
+		}
+		d, ok := ex.event["duration"].(float64)
+		return d, ok
+	}
+
+	baseline, ok := send(0)
+	if !ok {
+		return false, ""
+	}
+
+	observed := make([]string, 0, td.Requests)
+	for _, delay := range confirmationDelays(td) {
+		if ctx.Err() != nil {
+			return false, ""
+		}
+		d, ok := send(delay)
+		if !ok {
+			return false, ""
+		}
+		expected := float64(delay)
+		tolerance := math.Max(delayToleranceSeconds, expected*0.5)
+		if d-baseline < expected*0.9 || d > expected+baseline+tolerance {
+			return false, ""
+		}
+		observed = append(observed, fmt.Sprintf("%ds→%.2fs", delay, d))
+	}
+	return true, fmt.Sprintf("time_delay: 基线 %.2fs, %s", baseline, strings.Join(observed, ", "))
+}
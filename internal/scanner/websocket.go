@@ -0,0 +1,256 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+
+	"github.com/gorilla/websocket"
+	"gopkg.in/yaml.v3"
+)
+
+// wsDrainTimeout 发送完成后继续等待服务端推送消息的空闲时间
+const wsDrainTimeout = time.Second
+
+// maxWSMessages 单个连接最多收集的消息数
+const maxWSMessages = 100
+
+// WebSocketInput WebSocket 请求中发送的单条消息
+type WebSocketInput struct {
+	Data string // 消息内容（支持变量）
+	Name string // 发送后收到的消息以 name 作为 part
+}
+
+// WebSocketRequest WebSocket 请求配置
+type WebSocketRequest struct {
+	Address           string            // 连接地址（默认 {{BaseURL}}，http/https 自动转换为 ws/wss）
+	Headers           map[string]string // 握手请求头（支持变量）
+	Inputs            []WebSocketInput
+	Matchers          []Matcher
+	MatchersCondition string
+	Extractors        []Extractor
+}
+
+// nucleiWebSocketInput Nuclei WebSocket inputs 结构
+type nucleiWebSocketInput struct {
+	Data string `yaml:"data"`
+	Name string `yaml:"name"`
+}
+
+// nucleiWebSocketReq Nuclei WebSocket 请求结构
+type nucleiWebSocketReq struct {
+	Address           string                 `yaml:"address"`
+	Headers           map[string]string      `yaml:"headers"`
+	Inputs            []nucleiWebSocketInput `yaml:"inputs"`
+	MatchersCondition string                 `yaml:"matchers-condition"`
+	Matchers          []nucleiMatcher        `yaml:"matchers"`
+	Extractors        []nucleiExtractor      `yaml:"extractors"`
+}
+
+// parseWebSocketRequests 解析模板中的 websocket 块（matcher / extractor 默认 part 为 data）
+func parseWebSocketRequests(content string) ([]WebSocketRequest, error) {
+	var nt nucleiYAML
+	if err := yaml.Unmarshal([]byte(content), &nt); err != nil {
+		return nil, fmt.Errorf("YAML 解析失败: %w", err)
+	}
+
+	var requests []WebSocketRequest
+	for _, nw := range nt.WebSocket {
+		req := WebSocketRequest{
+			Address:           nw.Address,
+			Headers:           nw.Headers,
+			MatchersCondition: nw.MatchersCondition,
+			Matchers:          convertMatchers(nw.Matchers),
+			Extractors:        convertExtractors(nw.Extractors),
+		}
+		if req.Address == "" {
+			req.Address = "{{BaseURL}}"
+		}
+		for _, in := range nw.Inputs {
+			req.Inputs = append(req.Inputs, WebSocketInput{Data: in.Data, Name: in.Name})
+		}
+		for i := range req.Matchers {
+			if req.Matchers[i].Part == "" {
+				req.Matchers[i].Part = "data"
+			}
+		}
+		for i := range req.Extractors {
+			if req.Extractors[i].Part == "" {
+				req.Extractors[i].Part = "data"
+			}
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// executeWebSocketTemplate 依次执行模板中的 WebSocket 请求
+// 结果的主机统一为目标 BaseURL（与未命中、失败结果一致，对比扫描按此匹配），连接地址记录在请求中
+func (s *Scanner) executeWebSocketTemplate(ctx context.Context, job *ScanJob, info *targetInfo, template models.POCTemplate, requests []WebSocketRequest) []*models.ScanResult {
+	host := info.BaseURL
+	tctx, err := s.newProtocolContext(job, info, template, len(requests))
+	if err != nil {
+		return []*models.ScanResult{newErrorResult(template, host, err.Error())}
+	}
+
+	var results []*models.ScanResult
+	var lastErr error
+	for _, req := range requests {
+		if ctx.Err() != nil {
+			break
+		}
+		vars := tctx.variables()
+		addr, err := expandVariables(req.Address, vars)
+		if err != nil {
+			results = append(results, newErrorResult(template, host, err.Error()))
+			return results
+		}
+		headers := http.Header{}
+		for k, v := range req.Headers {
+			expanded, err := expandVariables(v, vars)
+			if err != nil {
+				results = append(results, newErrorResult(template, host, err.Error()))
+				return results
+			}
+			headers.Set(k, expanded)
+		}
+
+		if err := jobLimiter(job).wait(ctx); err != nil {
+			break
+		}
+		ex, err := sendWebSocketRequest(ctx, webSocketDialer(job), req, wsURL(addr), headers, vars, jobTimeout(job))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if r := s.matchProtocolEvent(tctx, template, host, req.Matchers, req.MatchersCondition, req.Extractors, ex.event, ex.request, ex.response); r != nil {
+			results = append(results, r)
+		}
+	}
+	results = append(results, s.resolveOASTChecks(ctx, tctx)...)
+	return finishResults(results, template, host, lastErr)
+}
+
+// wsURL 将 http/https 地址转换为 ws/wss
+func wsURL(addr string) string {
+	switch {
+	case strings.HasPrefix(addr, "http://"):
+		return "ws://" + strings.TrimPrefix(addr, "http://")
+	case strings.HasPrefix(addr, "https://"):
+		return "wss://" + strings.TrimPrefix(addr, "https://")
+	case !strings.Contains(addr, "://"):
+		return "ws://" + addr
+	}
+	return addr
+}
+
+// webSocketDialer 按扫描的 HTTP 传输层创建拨号器，代理和 TLS 配置与 HTTP 请求一致
+func webSocketDialer(job *ScanJob) *websocket.Dialer {
+	dialer := &websocket.Dialer{HandshakeTimeout: jobTimeout(job)}
+	if job != nil && job.transport != nil {
+		dialer.Proxy = job.transport.Proxy
+		dialer.TLSClientConfig = job.transport.TLSClientConfig.Clone()
+	}
+	return dialer
+}
+
+// sendWebSocketRequest 完成握手后发送消息并收集服务端消息
+// 事件中 data 为全部收到的消息（按行拼接），header / status_code 为握手响应；
+// 握手被拒绝（非 101）时仍返回握手响应，供 matcher 判断是否允许跨域连接等
+func sendWebSocketRequest(ctx context.Context, dialer *websocket.Dialer, req WebSocketRequest, addr string, headers http.Header, vars map[string]interface{}, timeout time.Duration) (*httpExchange, error) {
+	if _, err := url.Parse(addr); err != nil {
+		return nil, fmt.Errorf("无效的 WebSocket 地址 %s: %v", addr, err)
+	}
+
+	start := time.Now()
+	conn, resp, err := dialer.DialContext(ctx, addr, headers)
+	if err != nil && (resp == nil || !errors.Is(err, websocket.ErrBadHandshake)) {
+		return nil, fmt.Errorf("WebSocket 握手失败: %v", err)
+	}
+
+	var handshake strings.Builder
+	fmt.Fprintf(&handshake, "%s %s\r\n", resp.Proto, resp.Status)
+	resp.Header.Write(&handshake)
+
+	event := map[string]interface{}{
+		"header":      handshake.String(),
+		"status_code": resp.StatusCode,
+	}
+	var messages, sent []string
+	if conn != nil {
+		defer conn.Close()
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+		defer stop()
+		messages, sent, err = exchangeWebSocket(conn, req.Inputs, vars, event, timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data := strings.Join(messages, "\n")
+	event["data"] = data
+	event["body"] = data
+	event["raw"] = handshake.String() + "\r\n" + data
+	event["request"] = strings.Join(sent, "\n")
+	event["duration"] = time.Since(start).Seconds()
+
+	var request strings.Builder
+	fmt.Fprintf(&request, "GET %s\r\n", addr)
+	headers.Write(&request)
+	request.WriteString("\r\n")
+	for _, m := range sent {
+		request.WriteString("> " + m + "\n")
+	}
+	response := event["raw"].(string)
+	if len(response) > 4096 {
+		response = response[:4096] + "\n... (truncated)"
+	}
+	return &httpExchange{
+		event:    event,
+		request:  request.String(),
+		response: response,
+	}, nil
+}
+
+// exchangeWebSocket 依次发送消息，每条消息发送后读取一条回复（命名 input 的回复以其 name 写入事件），
+// 最后收集服务端继续推送的消息
+func exchangeWebSocket(conn *websocket.Conn, inputs []WebSocketInput, vars map[string]interface{}, event map[string]interface{}, timeout time.Duration) (messages, sent []string, err error) {
+	for _, in := range inputs {
+		data, err := expandVariables(in.Data, vars)
+		if err != nil {
+			return nil, nil, err
+		}
+		conn.SetWriteDeadline(time.Now().Add(timeout))
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(data)); err != nil {
+			return nil, nil, fmt.Errorf("发送失败: %v", err)
+		}
+		sent = append(sent, data)
+
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		messages = append(messages, string(msg))
+		if in.Name != "" {
+			event[in.Name] = string(msg)
+		}
+	}
+	// 服务端可能在握手后或回复之后继续推送消息
+	for len(messages) < maxWSMessages {
+		conn.SetReadDeadline(time.Now().Add(wsDrainTimeout))
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		messages = append(messages, string(msg))
+	}
+
+	return messages, sent, nil
+}
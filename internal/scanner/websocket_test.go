@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// echoWebSocket 原样返回收到的消息
+func echoWebSocket(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	for {
+		mt, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(mt, msg)
+	}
+}
+
+const echoTemplate = `id: ws-echo
+info: {name: ws-echo}
+websocket:
+  - inputs:
+      - data: hello
+    matchers:
+      - type: word
+        words: ["hello"]
+`
+
+func TestWebSocketUsesScanOptions(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(echoWebSocket))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(echoWebSocket))
+	defer secure.Close()
+
+	// 记录经过代理的 CONNECT 请求
+	var mu sync.Mutex
+	var tunnels []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		mu.Lock()
+		tunnels = append(tunnels, r.Host)
+		mu.Unlock()
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go io.Copy(upstream, buf)
+		io.Copy(conn, upstream)
+	}))
+	defer proxy.Close()
+
+	tests := []struct {
+		name       string
+		target     string
+		proxy      string
+		wantTunnel bool
+		wantErr    string // 为空表示应命中
+	}{
+		{name: "direct", target: plain.URL},
+		{name: "proxy", target: plain.URL, proxy: proxy.URL, wantTunnel: true},
+		// 与 HTTP 请求一样校验证书，不对 WebSocket 单独跳过
+		{name: "certificate verified", target: secure.URL, wantErr: "certificate"},
+	}
+	s := NewScanner(t.TempDir())
+	template := models.POCTemplate{ID: "ws-echo", Content: echoTemplate}
+	for _, tt := range tests {
+		mu.Lock()
+		tunnels = nil
+		mu.Unlock()
+
+		opts := models.ScanOptions{AllowPrivate: true, Timeout: 2, ProxyURL: tt.proxy}
+		id, err := s.Start(context.Background(), []string{tt.target}, "", []models.POCTemplate{template}, nil, "", opts, "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitScan(t, s, id)
+		results, _ := s.GetResults(id)
+		if len(results) != 1 {
+			t.Fatalf("%s: results = %+v", tt.name, results)
+		}
+		r := results[0]
+		if tt.wantErr == "" && r.Matched == "" {
+			t.Errorf("%s: expected a match, got %+v", tt.name, r)
+		}
+		if tt.wantErr != "" && !strings.Contains(r.Error, tt.wantErr) {
+			t.Errorf("%s: error = %q, want %q", tt.name, r.Error, tt.wantErr)
+		}
+
+		mu.Lock()
+		tunneled := len(tunnels) > 0 && tunnels[0] == strings.TrimPrefix(tt.target, "http://")
+		mu.Unlock()
+		if tunneled != tt.wantTunnel {
+			t.Errorf("%s: tunnels = %v, want tunnel = %v", tt.name, tunnels, tt.wantTunnel)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"context"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+// workflowRequestCount 工作流在单个目标上的请求总数（包含全部子模板，未执行的分支在结束时一并计入进度）
+func workflowRequestCount(wf models.Workflow, templatesDir string) int {
+	return stepsRequestCount(wf.Steps, templatesDir)
+}
+
+// stepsRequestCount 统计工作流步骤及其子模板的请求数
+func stepsRequestCount(steps []models.WorkflowStep, templatesDir string) int {
+	n := 0
+	for _, step := range steps {
+		for _, t := range step.Templates {
+			n += countTemplateRequests(t, templatesDir)
+		}
+		n += stepsRequestCount(step.Subtemplates, templatesDir)
+		for _, m := range step.Matchers {
+			n += stepsRequestCount(m.Subtemplates, templatesDir)
+		}
+	}
+	return n
+}
+
+// runWorkflowSteps 依次执行工作流步骤：步骤中任一模板命中后执行其 subtemplates，
+// 命中的命名 matcher 满足分支条件时执行该分支的子模板
+// run 在同一目标上执行单个模板
+func runWorkflowSteps(ctx context.Context, steps []models.WorkflowStep, run func(models.POCTemplate) []*models.ScanResult) []*models.ScanResult {
+	var results []*models.ScanResult
+	for _, step := range steps {
+		matched := false
+		names := make(map[string]bool)
+		for _, t := range step.Templates {
+			if ctx.Err() != nil {
+				return results
+			}
+			for _, r := range run(t) {
+				results = append(results, r)
+				if r == nil || r.Matched == "" {
+					continue
+				}
+				matched = true
+				for _, name := range r.MatcherNames {
+					names[name] = true
+				}
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		results = append(results, runWorkflowSteps(ctx, step.Subtemplates, run)...)
+		for _, m := range step.Matchers {
+			if workflowMatcherHit(m, names) {
+				results = append(results, runWorkflowSteps(ctx, m.Subtemplates, run)...)
+			}
+		}
+	}
+	return results
+}
+
+// workflowMatcherHit 判断命中的 matcher 名称是否满足分支条件（and 需全部命中，默认任一命中）
+func workflowMatcherHit(m models.WorkflowMatcher, names map[string]bool) bool {
+	if len(m.Names) == 0 {
+		return false
+	}
+	for _, name := range m.Names {
+		hit := names[name]
+		if m.Condition == "and" && !hit {
+			return false
+		}
+		if m.Condition != "and" && hit {
+			return true
+		}
+	}
+	return m.Condition == "and"
+}
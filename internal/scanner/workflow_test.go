@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+func TestCheckMatchersNames(t *testing.T) {
+	event := map[string]interface{}{
+		"status_code": 200,
+		"body":        "<title>x; [drupal] y</title> wp-content",
+	}
+	tests := []struct {
+		name     string
+		matchers []Matcher
+		cond     string
+		want     []string
+	}{
+		{
+			name: "named hits",
+			matchers: []Matcher{
+				{Name: "wordpress", Type: "word", Part: "body", Words: []string{"wp-content"}},
+				{Name: "joomla", Type: "word", Part: "body", Words: []string{"com_content"}},
+				{Type: "status", Status: []int{200}},
+			},
+			want: []string{"wordpress"},
+		},
+		{
+			// 响应内容中的 [name] 不能被当成命中的命名 matcher
+			name: "names are not parsed from hits",
+			matchers: []Matcher{
+				{Type: "regex", Part: "body", Regex: []string{`<title>.*</title>`}},
+			},
+			want: nil,
+		},
+		{
+			name: "negative named matcher",
+			matchers: []Matcher{
+				{Name: "not-joomla", Type: "word", Part: "body", Words: []string{"com_content"}, Negative: true},
+			},
+			want: []string{"not-joomla"},
+		},
+		{
+			name: "and condition",
+			matchers: []Matcher{
+				{Name: "wordpress", Type: "word", Part: "body", Words: []string{"wp-content"}},
+				{Name: "ok", Type: "status", Status: []int{200}},
+			},
+			cond: "and",
+			want: []string{"wordpress", "ok"},
+		},
+	}
+	for _, tt := range tests {
+		matched, hit := checkMatchers(tt.matchers, event, tt.cond)
+		if !matched {
+			t.Errorf("%s: expected a match", tt.name)
+			continue
+		}
+		if strings.Join(hit.names, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: names = %v, want %v", tt.name, hit.names, tt.want)
+		}
+	}
+}
+
+func TestRunWorkflowStepsBranches(t *testing.T) {
+	detect := models.POCTemplate{ID: "detect"}
+	branch := func(id string) models.WorkflowStep {
+		return models.WorkflowStep{Templates: []models.POCTemplate{{ID: id}}}
+	}
+	steps := []models.WorkflowStep{{
+		Templates:    []models.POCTemplate{detect},
+		Subtemplates: []models.WorkflowStep{branch("always")},
+		Matchers: []models.WorkflowMatcher{
+			{Names: []string{"wordpress"}, Subtemplates: []models.WorkflowStep{branch("wp")}},
+			{Names: []string{"drupal"}, Subtemplates: []models.WorkflowStep{branch("drupal")}},
+			{Names: []string{"wordpress", "drupal"}, Condition: "and", Subtemplates: []models.WorkflowStep{branch("both")}},
+		},
+	}}
+
+	tests := []struct {
+		name   string
+		detect *models.ScanResult // detect 模板的结果
+		want   string             // 执行过的模板（按顺序）
+	}{
+		{
+			name:   "no match",
+			detect: &models.ScanResult{TemplateID: "detect", Error: msgNoMatch},
+			want:   "detect",
+		},
+		{
+			name:   "named matcher",
+			detect: &models.ScanResult{TemplateID: "detect", Matched: "[wordpress] word(body): wp-content", MatcherNames: []string{"wordpress"}},
+			want:   "detect,always,wp",
+		},
+		{
+			// 匹配信息中来自响应内容的 [drupal] 不触发分支
+			name:   "display text is ignored",
+			detect: &models.ScanResult{TemplateID: "detect", Matched: "regex(body): <title>x; [drupal] y</title>"},
+			want:   "detect,always",
+		},
+		{
+			name:   "and condition",
+			detect: &models.ScanResult{TemplateID: "detect", Matched: "x", MatcherNames: []string{"wordpress", "drupal"}},
+			want:   "detect,always,wp,drupal,both",
+		},
+	}
+	for _, tt := range tests {
+		var ran []string
+		runWorkflowSteps(context.Background(), steps, func(tmpl models.POCTemplate) []*models.ScanResult {
+			ran = append(ran, tmpl.ID)
+			if tmpl.ID == "detect" {
+				return []*models.ScanResult{tt.detect}
+			}
+			return []*models.ScanResult{{TemplateID: tmpl.ID, Error: msgNoMatch}}
+		})
+		if got := strings.Join(ran, ","); got != tt.want {
+			t.Errorf("%s: ran %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@every",
+	}
+	for _, expr := range tests {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) expected error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want string // 为空表示永远不会触发
+	}{
+		{name: "step", expr: "*/15 * * * *", from: "2024-01-01 10:07:30", want: "2024-01-01 10:15:00"},
+		{name: "excludes from", expr: "*/15 * * * *", from: "2024-01-01 10:15:00", want: "2024-01-01 10:30:00"},
+		{name: "value with step", expr: "5/20 * * * *", from: "2024-01-01 10:06:00", want: "2024-01-01 10:25:00"},
+		{name: "list and range", expr: "0,30 8-9 * * *", from: "2024-01-01 09:30:00", want: "2024-01-02 08:00:00"},
+		{name: "weekdays", expr: "0 9 * * 1-5", from: "2024-01-05 10:00:00", want: "2024-01-08 09:00:00"},
+		{name: "sunday as 7", expr: "0 0 * * 7", from: "2024-01-01 00:00:00", want: "2024-01-07 00:00:00"},
+		{name: "day of month", expr: "30 2 1 * *", from: "2024-01-15 00:00:00", want: "2024-02-01 02:30:00"},
+		// 日和周都指定时任一满足即触发：1 月 13 日之前先遇到周五
+		{name: "day or weekday", expr: "0 0 13 * 5", from: "2024-01-01 00:00:00", want: "2024-01-05 00:00:00"},
+		{name: "day and any weekday", expr: "0 0 13 * *", from: "2024-01-01 00:00:00", want: "2024-01-13 00:00:00"},
+		{name: "month", expr: "0 0 1 6 *", from: "2024-07-01 00:00:00", want: "2025-06-01 00:00:00"},
+		{name: "leap day", expr: "0 0 29 2 *", from: "2024-03-01 00:00:00", want: "2028-02-29 00:00:00"},
+		{name: "alias", expr: "@daily", from: "2024-01-01 00:00:00", want: "2024-01-02 00:00:00"},
+		{name: "alias case", expr: " @Hourly ", from: "2024-01-01 10:59:59", want: "2024-01-01 11:00:00"},
+		{name: "never", expr: "0 0 30 2 *", from: "2024-01-01 00:00:00"},
+	}
+	for _, tt := range tests {
+		c, err := parseCron(tt.expr)
+		if err != nil {
+			t.Errorf("%s: parseCron(%q): %v", tt.name, tt.expr, err)
+			continue
+		}
+		got := c.next(at(tt.from))
+		if tt.want == "" {
+			if !got.IsZero() {
+				t.Errorf("%s: next = %v, want never", tt.name, got)
+			}
+			continue
+		}
+		if want := at(tt.want); !got.Equal(want) {
+			t.Errorf("%s: next(%s) = %v, want %v", tt.name, tt.from, got, want)
+		}
+	}
+}
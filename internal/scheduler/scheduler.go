@@ -0,0 +1,330 @@
+// Package scheduler 定时扫描：按 cron 表达式或固定间隔重复启动保存的扫描请求
+// 定时任务和执行记录保存在数据目录的 schedules.json 中，应用关闭期间错过的执行按任务的策略跳过或补执行一次
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+const (
+	MissedSkip    = "skip"     // 跳过错过的执行
+	MissedRunOnce = "run-once" // 错过的执行（无论错过几次）补执行一次
+
+	maxHistory  = 50          // 每个定时任务保留的执行记录数
+	missedGrace = time.Minute // 晚于计划时间超过该值视为错过（应用关闭或系统休眠）
+)
+
+// RunFunc 启动一次扫描，返回扫描 ID
+type RunFunc func(models.ScanRequest) (string, error)
+
+// Scheduler 定时扫描调度器
+type Scheduler struct {
+	path      string // 定时任务持久化文件
+	run       RunFunc
+	schedules []*models.Schedule
+	mu        sync.Mutex
+	wake      chan struct{} // 定时任务变化后重新计算等待时间
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewScheduler 创建调度器并加载已保存的定时任务（调用 Start 后开始调度）
+func NewScheduler(dataDir string, run RunFunc) *Scheduler {
+	s := &Scheduler{
+		path: filepath.Join(dataDir, "schedules.json"),
+		run:  run,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	if data, err := os.ReadFile(s.path); err == nil {
+		json.Unmarshal(data, &s.schedules)
+	}
+	return s
+}
+
+// Start 开始调度；已到期的定时任务（包括应用关闭期间错过的）立即按策略处理
+func (s *Scheduler) Start() {
+	go s.loop()
+}
+
+// Stop 停止调度
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+// List 返回全部定时任务（按创建时间排序）
+func (s *Scheduler) List() []models.Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]models.Schedule, 0, len(s.schedules))
+	for _, sched := range s.schedules {
+		list = append(list, copySchedule(sched))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// Create 创建定时任务
+func (s *Scheduler) Create(sched models.Schedule) (*models.Schedule, error) {
+	if err := validate(&sched); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sched.ID = fmt.Sprintf("schedule_%d", now.UnixNano())
+	sched.CreatedAt = now
+	sched.UpdatedAt = now
+	sched.LastRun = time.Time{}
+	sched.History = nil
+	sched.NextRun = nextRun(&sched, now, now)
+
+	s.mu.Lock()
+	s.schedules = append(s.schedules, &sched)
+	err := s.saveLocked()
+	result := copySchedule(&sched)
+	s.mu.Unlock()
+
+	s.notify()
+	return &result, err
+}
+
+// Update 修改定时任务；执行记录保留，修改了执行时间或重新启用时从现在起重新计算下次执行时间
+func (s *Scheduler) Update(sched models.Schedule) (*models.Schedule, error) {
+	if err := validate(&sched); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	old := s.findLocked(sched.ID)
+	if old == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("定时任务不存在: %s", sched.ID)
+	}
+	now := time.Now()
+	timingChanged := old.Cron != sched.Cron || old.Interval != sched.Interval || (!old.Enabled && sched.Enabled)
+	old.Name = sched.Name
+	old.Enabled = sched.Enabled
+	old.Cron = sched.Cron
+	old.Interval = sched.Interval
+	old.MissedPolicy = sched.MissedPolicy
+	old.Request = sched.Request
+	old.UpdatedAt = now
+	if timingChanged || old.NextRun.IsZero() {
+		old.NextRun = nextRun(old, now, now)
+	}
+	err := s.saveLocked()
+	result := copySchedule(old)
+	s.mu.Unlock()
+
+	s.notify()
+	return &result, err
+}
+
+// Delete 删除定时任务（已启动的扫描不受影响）
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, sched := range s.schedules {
+		if sched.ID == id {
+			s.schedules = append(s.schedules[:i], s.schedules[i+1:]...)
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("定时任务不存在: %s", id)
+}
+
+// loop 等待最早到期的定时任务并执行
+func (s *Scheduler) loop() {
+	for {
+		s.mu.Lock()
+		var next time.Time
+		for _, sched := range s.schedules {
+			if sched.Enabled && !sched.NextRun.IsZero() && (next.IsZero() || sched.NextRun.Before(next)) {
+				next = sched.NextRun
+			}
+		}
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+		select {
+		case <-s.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-s.wake:
+		case <-fire:
+			s.runDue(time.Now())
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// runDue 执行所有已到期的定时任务：按时到期的正常执行，错过的按策略跳过或补执行一次
+func (s *Scheduler) runDue(now time.Time) {
+	type dueRun struct {
+		id      string
+		planned time.Time
+		request models.ScanRequest
+	}
+	var runs []dueRun
+
+	s.mu.Lock()
+	for _, sched := range s.schedules {
+		if !sched.Enabled || sched.NextRun.IsZero() || sched.NextRun.After(now) {
+			continue
+		}
+		planned := sched.NextRun
+		sched.NextRun = nextRun(sched, planned, now)
+		if now.Sub(planned) > missedGrace && sched.MissedPolicy != MissedRunOnce {
+			addHistory(sched, models.ScheduleRun{Time: planned, Skipped: true})
+			continue
+		}
+
+		req := sched.Request
+		base := req.Name
+		if base == "" {
+			base = sched.Name
+		}
+		// 扫描名称同时作为扫描 ID，每次执行加上时间和定时任务 ID，
+		// 同名的定时任务在同一秒执行时也不会使用相同的扫描 ID
+		req.Name = base + "-" + now.Format("20060102-150405") + "-" + sched.ID
+		runs = append(runs, dueRun{id: sched.ID, planned: planned, request: req})
+	}
+	s.saveLocked()
+	s.mu.Unlock()
+
+	for _, r := range runs {
+		entry := models.ScheduleRun{Time: r.planned, RanAt: time.Now()}
+		scanID, err := s.run(r.request)
+		entry.ScanID = scanID
+		if err != nil {
+			entry.Error = err.Error()
+		}
+
+		s.mu.Lock()
+		if sched := s.findLocked(r.id); sched != nil {
+			sched.LastRun = entry.RanAt
+			addHistory(sched, entry)
+			s.saveLocked()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// notify 唤醒调度循环重新计算等待时间
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// findLocked 按 ID 查找定时任务（调用方持有 s.mu）
+func (s *Scheduler) findLocked(id string) *models.Schedule {
+	for _, sched := range s.schedules {
+		if sched.ID == id {
+			return sched
+		}
+	}
+	return nil
+}
+
+// saveLocked 保存全部定时任务（先写临时文件再重命名，调用方持有 s.mu）
+func (s *Scheduler) saveLocked() error {
+	data, err := json.MarshalIndent(s.schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("保存定时任务失败: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("保存定时任务失败: %v", err)
+	}
+	return nil
+}
+
+// validate 校验定时任务并补全默认值
+func validate(sched *models.Schedule) error {
+	if (sched.Cron == "") == (sched.Interval <= 0) {
+		return fmt.Errorf("需要设置 cron 表达式或执行间隔（二选一）")
+	}
+	if sched.Cron != "" {
+		c, err := parseCron(sched.Cron)
+		if err != nil {
+			return err
+		}
+		if c.next(time.Now()).IsZero() {
+			return fmt.Errorf("cron 表达式不会触发: %s", sched.Cron)
+		}
+	}
+	switch sched.MissedPolicy {
+	case "":
+		sched.MissedPolicy = MissedSkip
+	case MissedSkip, MissedRunOnce:
+	default:
+		return fmt.Errorf("未知的错过执行策略: %s", sched.MissedPolicy)
+	}
+	if len(sched.Request.Targets) == 0 && sched.Request.TargetsFile == "" {
+		return fmt.Errorf("定时扫描需要至少一个目标")
+	}
+	if len(sched.Request.TemplateIDs) == 0 && len(sched.Request.WorkflowIDs) == 0 {
+		return fmt.Errorf("定时扫描需要至少一个模板")
+	}
+	if sched.Name == "" {
+		sched.Name = "定时扫描"
+	}
+	return nil
+}
+
+// nextRun 计算 planned 之后的下次执行时间（不早于 now）
+// 固定间隔按计划时间累加保持节奏，错过时从 now 起重新计算
+func nextRun(sched *models.Schedule, planned, now time.Time) time.Time {
+	if sched.Cron != "" {
+		c, err := parseCron(sched.Cron)
+		if err != nil {
+			return time.Time{}
+		}
+		return c.next(now)
+	}
+	interval := time.Duration(sched.Interval) * time.Minute
+	next := planned.Add(interval)
+	if !next.After(now) {
+		next = now.Add(interval)
+	}
+	return next
+}
+
+// addHistory 记录一次执行（新的在前，只保留最近 maxHistory 条）
+func addHistory(sched *models.Schedule, run models.ScheduleRun) {
+	sched.History = append([]models.ScheduleRun{run}, sched.History...)
+	if len(sched.History) > maxHistory {
+		sched.History = sched.History[:maxHistory]
+	}
+}
+
+// copySchedule 复制定时任务（返回给调用方，避免共享内部状态）
+func copySchedule(sched *models.Schedule) models.Schedule {
+	c := *sched
+	c.History = append([]models.ScheduleRun(nil), sched.History...)
+	return c
+}
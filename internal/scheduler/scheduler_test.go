@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"nuclei-poc-manager/internal/models"
+)
+
+func TestNextRun(t *testing.T) {
+	planned := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		sched models.Schedule
+		now   time.Time
+		want  time.Time
+	}{
+		{
+			// 按时执行时沿计划时间累加，不因执行延迟漂移
+			name:  "interval on time",
+			sched: models.Schedule{Interval: 60},
+			now:   planned.Add(30 * time.Second),
+			want:  planned.Add(time.Hour),
+		},
+		{
+			name:  "interval missed",
+			sched: models.Schedule{Interval: 60},
+			now:   planned.Add(3*time.Hour + 20*time.Minute),
+			want:  planned.Add(4*time.Hour + 20*time.Minute),
+		},
+		{
+			name:  "cron",
+			sched: models.Schedule{Cron: "0 * * * *"},
+			now:   planned.Add(3*time.Hour + 20*time.Minute),
+			want:  planned.Add(4 * time.Hour),
+		},
+		{
+			name:  "invalid cron",
+			sched: models.Schedule{Cron: "bad"},
+			now:   planned,
+		},
+	}
+	for _, tt := range tests {
+		if got := nextRun(&tt.sched, planned, tt.now); !got.Equal(tt.want) {
+			t.Errorf("%s: nextRun = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunDueMissedPolicy(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	missed := now.Add(-3 * time.Hour) // 应用关闭期间错过了 3 次
+	request := models.ScanRequest{Targets: []string{"a.com"}, TemplateIDs: []string{"t"}}
+
+	tests := []struct {
+		sched   models.Schedule
+		wantRun bool
+		wantHis string // 执行记录：run / skip，为空表示没有记录
+		next    time.Time
+	}{
+		{
+			// 在允许的延迟内视为按时执行
+			sched:   models.Schedule{ID: "on-time", Interval: 60, MissedPolicy: MissedSkip, NextRun: now.Add(-30 * time.Second)},
+			wantRun: true,
+			wantHis: "run",
+			next:    now.Add(-30 * time.Second).Add(time.Hour),
+		},
+		{
+			sched:   models.Schedule{ID: "skip", Interval: 60, MissedPolicy: MissedSkip, NextRun: missed},
+			wantHis: "skip",
+			next:    now.Add(time.Hour),
+		},
+		{
+			// 无论错过几次都只补执行一次
+			sched:   models.Schedule{ID: "run-once", Interval: 60, MissedPolicy: MissedRunOnce, NextRun: missed},
+			wantRun: true,
+			wantHis: "run",
+			next:    now.Add(time.Hour),
+		},
+		{
+			sched:   models.Schedule{ID: "cron", Cron: "0 * * * *", MissedPolicy: MissedRunOnce, NextRun: missed},
+			wantRun: true,
+			wantHis: "run",
+			next:    now.Add(time.Hour),
+		},
+		{
+			sched: models.Schedule{ID: "future", Interval: 60, MissedPolicy: MissedSkip, NextRun: now.Add(time.Minute)},
+			next:  now.Add(time.Minute),
+		},
+	}
+
+	// 扫描名称以定时任务 ID 结尾，按 ID 统计执行次数
+	ran := make(map[string]int)
+	dir := t.TempDir()
+	s := NewScheduler(dir, func(req models.ScanRequest) (string, error) {
+		for _, tt := range tests {
+			if strings.HasSuffix(req.Name, "-"+tt.sched.ID) {
+				ran[tt.sched.ID]++
+			}
+		}
+		return "scan-" + req.Name, nil
+	})
+	for i := range tests {
+		sched := tests[i].sched
+		sched.Name = sched.ID
+		sched.Enabled = true
+		sched.Request = request
+		s.schedules = append(s.schedules, &sched)
+	}
+	disabled := &models.Schedule{ID: "disabled", Interval: 60, NextRun: missed, Request: request}
+	s.schedules = append(s.schedules, disabled)
+
+	s.runDue(now)
+	s.runDue(now) // 同一时间再次检查不会重复执行
+
+	for _, tt := range tests {
+		id := tt.sched.ID
+		sched := s.findLocked(id)
+		want := 0
+		if tt.wantRun {
+			want = 1
+		}
+		if ran[id] != want {
+			t.Errorf("%s: ran %d times, want %d", id, ran[id], want)
+		}
+		if !sched.NextRun.Equal(tt.next) {
+			t.Errorf("%s: next run = %v, want %v", id, sched.NextRun, tt.next)
+		}
+
+		var his []string
+		for _, h := range sched.History {
+			switch {
+			case h.Skipped && h.RanAt.IsZero() && h.ScanID == "":
+				his = append(his, "skip")
+			case !h.Skipped && !h.RanAt.IsZero() && strings.HasPrefix(h.ScanID, "scan-"):
+				his = append(his, "run")
+			default:
+				his = append(his, "invalid")
+			}
+			if !h.Time.Equal(tt.sched.NextRun) {
+				t.Errorf("%s: history time = %v, want the planned time %v", id, h.Time, tt.sched.NextRun)
+			}
+		}
+		if got := strings.Join(his, ","); got != tt.wantHis {
+			t.Errorf("%s: history = %s, want %s", id, got, tt.wantHis)
+		}
+		if tt.wantRun && sched.LastRun.IsZero() {
+			t.Errorf("%s: last run not recorded", id)
+		}
+	}
+	if len(disabled.History) != 0 || !disabled.NextRun.Equal(missed) {
+		t.Errorf("disabled schedule was processed: %+v", disabled)
+	}
+
+	// 执行记录随定时任务保存
+	reloaded := NewScheduler(dir, nil)
+	if sched := reloaded.findLocked("skip"); sched == nil || len(sched.History) != 1 || !sched.History[0].Skipped {
+		t.Errorf("reloaded schedule = %+v", sched)
+	}
+}